@@ -0,0 +1,136 @@
+package lexopt
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// AliasError is returned by [Aliases.Expand] when an alias expands, directly
+// or indirectly, to itself.
+type AliasError struct {
+	Chain []string // the aliases involved, ending with the repeated one
+}
+
+func (e *AliasError) Error() string {
+	return fmt.Sprintf("recursive alias: %s", strings.Join(e.Chain, " -> "))
+}
+
+// Aliases maps an argument to the arguments it stands for, like git's user
+// aliases (st = status -sb) or presets (--ci = --no-color --jobs=1). Keys are
+// matched against [Arg.DashedString], so options are written with their
+// dashes, as in "--ci" or "-C", and positional arguments as themselves.
+//
+// Expansions may use other aliases, which are expanded in turn, up to the
+// first -- in the expansion. Only whole arguments are expanded straight
+// away: an alias "-x" inside an expansion is expanded, but one inside "-ax"
+// is left for [Aliases.Expand] to see when [Parser.Next] gets to it.
+//
+// An alias is never expanded inside its own expansion. As a whole argument,
+// that is an error, since it could only loop. As part of an argument, it
+// stands for the option itself, so that "--color": {"--color=always"} and
+// "-v": {"-vv"} mean what they say.
+type Aliases map[string][]string
+
+// Expand checks whether the parser's Current argument is an alias and, if
+// so, splices its expansion into the command line with [Parser.Insert] and
+// returns true. The expansion is seen by the next call to [Parser.Next].
+// Arguments after -- are never expanded.
+//
+// Expand returns an error wrapping an [AliasError] if the alias is recursive,
+// including through options that are part of arguments in an expansion, as
+// with "-a": {"-ab"} and "-b": {"-a"}; nothing is then inserted. It returns
+// an error wrapping [ErrUnexpectedValue] if the alias has a value attached,
+// as in --ci=yes.
+func (a Aliases) Expand(p *Parser) (bool, error) {
+	// Nothing after -- is an alias.
+	if p.state == finished {
+		return false, nil
+	}
+
+	name := p.Current.DashedString()
+	if _, ok := a[name]; !ok {
+		return false, nil
+	}
+
+	// Current may be part of an argument that came from expanding aliases,
+	// which it must not expand again.
+	var stack []string
+	for _, arg := range p.aliased {
+		if arg.idx == p.curpos.Index {
+			stack = arg.stack
+		}
+	}
+
+	if slices.Contains(stack, name) {
+		return false, nil
+	}
+
+	expansion, stacks, err := a.expand(name, stack)
+	if err != nil {
+		return false, p.currentErr(err)
+	}
+
+	at := p.idx
+	if err := p.Insert(expansion...); err != nil {
+		return false, err
+	}
+
+	aliased := p.aliased[:len(p.aliased):len(p.aliased)]
+	for i := range expansion {
+		aliased = append(aliased, aliasedArg{idx: at + i, stack: stacks[i]})
+	}
+	p.aliased = aliased
+
+	return true, nil
+}
+
+// An aliasedArg records that argv[idx] was inserted by Aliases.Expand, and the
+// aliases that were being expanded when it was, outermost first.
+type aliasedArg struct {
+	idx   int
+	stack []string
+}
+
+// expand returns the full expansion of the alias name, and the stack of
+// aliases that each argument in it came from. The stack is the list of
+// aliases currently being expanded, used to detect loops.
+func (a Aliases) expand(name string, stack []string) ([]string, [][]string, error) {
+	for i, seen := range stack {
+		if seen == name {
+			chain := append(stack[i:len(stack):len(stack)], name)
+			return nil, nil, &AliasError{Chain: chain}
+		}
+	}
+
+	// Each argument keeps its stack, so don't share the backing array.
+	stack = append(stack[:len(stack):len(stack)], name)
+
+	var out []string
+	var stacks [][]string
+	for i, arg := range a[name] {
+		if arg == "--" {
+			for _, arg := range a[name][i:] {
+				out = append(out, arg)
+				stacks = append(stacks, stack)
+			}
+			break
+		}
+
+		if _, ok := a[arg]; !ok {
+			out = append(out, arg)
+			stacks = append(stacks, stack)
+			continue
+		}
+
+		sub, subStacks, err := a.expand(arg, stack)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		out = append(out, sub...)
+		stacks = append(stacks, subStacks...)
+	}
+
+	return out, stacks, nil
+}
@@ -0,0 +1,166 @@
+package lexopt
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	aliases := Aliases{
+		"st":     {"status", "-sb"},
+		"--ci":   {"--no-color", "--jobs=1", "-F"},
+		"-F":     {"--fail-fast"},
+		"--all":  {"--ci", "-v"},
+		"--raw":  {"-F", "--", "-F"},
+		"--loop": {"--a"},
+		"--a":    {"--b"},
+		"--b":    {"--loop"},
+		"--self": {"-q", "--self"},
+	}
+
+	collect := func(t *testing.T, argv ...string) []string {
+		t.Helper()
+
+		p := NewFromArgs(argv)
+		var got []string
+		for p.Next() {
+			if ok, err := aliases.Expand(p); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if ok {
+				continue
+			}
+
+			got = append(got, p.Current.DashedString())
+			if p.Current == Long("jobs") {
+				val, _ := p.Value()
+				got = append(got, val.String())
+			}
+		}
+
+		return got
+	}
+
+	tests := []struct {
+		desc   string
+		argv   []string
+		expect []string
+	}{
+		{"positional", []string{"st", "file"}, []string{"status", "-s", "-b", "file"}},
+		{"nested", []string{"--ci", "x"}, []string{"--no-color", "--jobs", "1", "--fail-fast", "x"}},
+		{"twice nested", []string{"--all"}, []string{"--no-color", "--jobs", "1", "--fail-fast", "-v"}},
+		{"in a cluster", []string{"-aFb"}, []string{"-a", "--fail-fast", "-b"}},
+		{"stops at double dash", []string{"--raw"}, []string{"--fail-fast", "-F"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := collect(t, test.argv...); !reflect.DeepEqual(got, test.expect) {
+				t.Errorf("want %q, got %q", test.expect, got)
+			}
+		})
+	}
+
+	for _, name := range []string{"--loop", "--self"} {
+		t.Run("recursive "+name, func(t *testing.T) {
+			p := NewFromArgs([]string{name, "x"})
+			p.Next()
+			ok, err := aliases.Expand(p)
+			var aliasErr *AliasError
+			if ok || !errors.As(err, &aliasErr) {
+				t.Fatalf("expected an AliasError, got %v, %v", ok, err)
+			}
+
+			if aliasErr.Chain[0] != name {
+				t.Errorf("bad alias chain: %v", err)
+			}
+
+			p.Next()
+			if p.Current != Value("x") {
+				t.Errorf("recursive alias inserted something: %v", p.Current)
+			}
+		})
+	}
+
+	t.Run("with value", func(t *testing.T) {
+		p := NewFromArgs([]string{"--ci=yes"})
+		p.Next()
+		if _, err := aliases.Expand(p); !errors.Is(err, ErrUnexpectedValue) {
+			t.Errorf("expected ErrUnexpectedValue, got %v", err)
+		}
+	})
+}
+
+func TestAliasesSelfReference(t *testing.T) {
+	aliases := Aliases{
+		"-v":      {"-vv"},
+		"--color": {"--color=always"},
+		"-a":      {"-ab"},
+		"-b":      {"-a"},
+		"-c":      {"-ac"},
+	}
+
+	t.Run("in a cluster", func(t *testing.T) {
+		pt := newTester(t, "-v x")
+		var got []string
+		for pt.Next() {
+			if ok, err := aliases.Expand(pt.Parser); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if !ok {
+				got = append(got, pt.Current.DashedString())
+			}
+		}
+
+		if expect := []string{"-v", "-v", "x"}; !reflect.DeepEqual(got, expect) {
+			t.Errorf("want %q, got %q", expect, got)
+		}
+	})
+
+	t.Run("with a value", func(t *testing.T) {
+		pt := newTester(t, "--color")
+		pt.longOk("color")
+		if ok, err := aliases.Expand(pt.Parser); !ok || err != nil {
+			t.Fatalf("expected expansion, got %v, %v", ok, err)
+		}
+
+		pt.longOk("color")
+		if ok, err := aliases.Expand(pt.Parser); ok || err != nil {
+			t.Fatalf("expected no expansion, got %v, %v", ok, err)
+		}
+		pt.valueOk("always")
+		pt.emptyOk()
+	})
+
+	t.Run("loop through a cluster", func(t *testing.T) {
+		pt := newTester(t, "-a")
+		pt.shortOk('a')
+		aliases.Expand(pt.Parser)
+		pt.shortOk('a')
+		if ok, err := aliases.Expand(pt.Parser); ok || err != nil {
+			t.Fatalf("expected no expansion, got %v, %v", ok, err)
+		}
+
+		pt.shortOk('b')
+		_, err := aliases.Expand(pt.Parser)
+		var aliasErr *AliasError
+		if !errors.As(err, &aliasErr) || !reflect.DeepEqual(aliasErr.Chain, []string{"-a", "-b", "-a"}) {
+			t.Errorf("expected an AliasError for -a -> -b -> -a, got %v", err)
+		}
+	})
+
+	t.Run("mid-cluster", func(t *testing.T) {
+		pt := newTester(t, "-cd")
+		pt.shortOk('c')
+		aliases.Expand(pt.Parser)
+		pt.shortOk('a')
+		aliases.Expand(pt.Parser)
+		pt.shortOk('a')
+		pt.shortOk('b')
+		pt.shortOk('c')
+		if ok, err := aliases.Expand(pt.Parser); ok || err != nil {
+			t.Fatalf("expected no expansion, got %v, %v", ok, err)
+		}
+		pt.shortOk('d')
+		pt.emptyOk()
+	})
+}
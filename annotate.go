@@ -0,0 +1,275 @@
+package lexopt
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Annotate renders err like a compiler diagnostic: the error message,
+// followed by the parser's full command line (including [Parser.BinName])
+// with the offending part underlined. If err does not carry a position (see
+// [ArgError]), Annotate returns just the error message, and if err is nil,
+// the empty string. See [Annotate] for details about the width argument.
+//
+// Values of options in [Parser.Secrets] are redacted, both on the command
+// line and in the error message.
+func (p *Parser) Annotate(err error, width int) string {
+	if err == nil {
+		return ""
+	}
+
+	argv := p.redactArgv()
+	msg := p.redactErr(argv, err)
+
+	var argErr *ArgError
+	if !errors.As(err, &argErr) || argErr.Pos.Index >= len(argv) {
+		return msg
+	}
+
+	return annotate(msg, p.redactPos(argv, argErr.Pos), p.binName, argv, width)
+}
+
+// Annotate renders err like a compiler diagnostic. If err wraps an
+// [ArgError], the output is the error message followed by the command line
+// made of binName and argv, shell-quoted as needed, with carets under the
+// text that the error's [Position] refers to:
+//
+//	"abc" (argument 2): strconv.ParseInt: parsing "abc": invalid syntax
+//	  myapp -n abc
+//	           ^^^
+//
+// Here argv is the parser's argv, which does not include binName; binName
+// may be empty. If width is positive, the command line is truncated (with
+// "...") to fit in that many terminal columns, keeping the underlined part
+// in view. Wide characters, like those in CJK scripts, count as two columns.
+// A nil err, as from [Parser.Err] after a successful parse, gives the empty
+// string.
+func Annotate(err error, binName string, argv []string, width int) string {
+	if err == nil {
+		return ""
+	}
+
+	var argErr *ArgError
+	if !errors.As(err, &argErr) || argErr.Pos.Index >= len(argv) {
+		return err.Error()
+	}
+
+	return annotate(err.Error(), argErr.Pos, binName, argv, width)
+}
+
+// annotate does the work for Annotate, rendering msg with the text at pos
+// underlined.
+func annotate(msg string, pos Position, binName string, argv []string, width int) string {
+	const indent = "  "
+
+	// Lay out the command line as a series of cells, one per rune.
+	var cells []cell
+	if binName != "" {
+		cells = appendCells(cells, shellQuote(binName), -1, -1)
+		cells = append(cells, cell{r: ' ', width: 1})
+	}
+
+	spanStart := -1
+	for i, tok := range argv {
+		if i > 0 {
+			cells = append(cells, cell{r: ' ', width: 1})
+		}
+
+		if i != pos.Index {
+			cells = appendCells(cells, shellQuote(tok), -1, -1)
+			continue
+		}
+
+		spanStart = len(cells)
+		start, end := quotedOffset(tok, pos.Start), quotedOffset(tok, pos.End)
+		cells = appendCells(cells, shellQuote(tok), start, end)
+	}
+
+	// Find the underlined cells. An empty span, as for the value in -o=, gets
+	// a single caret where the text would have been.
+	from, to := -1, -1
+	for i, c := range cells {
+		if c.marked {
+			if from < 0 {
+				from = i
+			}
+			to = i + 1
+		}
+	}
+
+	if from < 0 {
+		start := quotedOffset(pos.Token, pos.Start)
+		from = spanStart + len([]rune(shellQuote(pos.Token)[:start]))
+		if from >= len(cells) {
+			cells = append(cells, cell{r: ' ', width: 1})
+		}
+		cells[from].marked = true
+		to = from + 1
+	}
+
+	first, last := 0, len(cells)
+	if width > 0 {
+		first, last = visibleCells(cells, from, to, width-len(indent))
+	}
+
+	var line, carets strings.Builder
+	line.WriteString(indent)
+	carets.WriteString(indent)
+
+	if first > 0 {
+		line.WriteString("...")
+		carets.WriteString("   ")
+	}
+
+	for _, c := range cells[first:last] {
+		line.WriteRune(c.r)
+
+		mark := " "
+		if c.marked {
+			mark = "^"
+		}
+		carets.WriteString(strings.Repeat(mark, c.width))
+	}
+
+	if last < len(cells) {
+		line.WriteString("...")
+	}
+
+	return msg + "\n" +
+		strings.TrimRight(line.String(), " ") + "\n" +
+		strings.TrimRight(carets.String(), " ")
+}
+
+// A cell is a single rune on the rendered command line.
+type cell struct {
+	r      rune
+	width  int  // in terminal columns
+	marked bool // whether it should be underlined
+}
+
+// appendCells appends the runes of s to cells, marking those that fall in the
+// byte range s[start:end].
+func appendCells(cells []cell, s string, start, end int) []cell {
+	for i, r := range s {
+		if !unicode.IsPrint(r) && !unicode.Is(unicode.Mn, r) {
+			r = '?'
+		}
+
+		cells = append(cells, cell{
+			r:      r,
+			width:  runeWidth(r),
+			marked: start <= i && i < end,
+		})
+	}
+
+	return cells
+}
+
+// visibleCells returns the range of cells to show so that the line fits in
+// avail columns, including the "..." on either side if the line is cut
+// short. The marked cells from:to are kept in view, as much as possible.
+func visibleCells(cells []cell, from, to, avail int) (int, int) {
+	const ellipsis = 3
+
+	cost := func(first, last int) int {
+		total := 0
+		for _, c := range cells[first:last] {
+			total += c.width
+		}
+		if first > 0 {
+			total += ellipsis
+		}
+		if last < len(cells) {
+			total += ellipsis
+		}
+		return total
+	}
+
+	if cost(0, len(cells)) <= avail {
+		return 0, len(cells)
+	}
+
+	// If even the marked text is too long, show as much of its start as fits.
+	for to > from+1 && cost(from, to) > avail {
+		to--
+	}
+
+	// Then grow outwards, alternating sides, while there's room.
+	first, last := from, to
+	for grew := true; grew; {
+		grew = false
+
+		if last < len(cells) && cost(first, last+1) <= avail {
+			last++
+			grew = true
+		}
+
+		if first > 0 && cost(first-1, last) <= avail {
+			first--
+			grew = true
+		}
+	}
+
+	return first, last
+}
+
+// runeWidth returns the number of terminal columns r takes up: 0 for
+// combining marks and other zero-width characters, 2 for East Asian wide and
+// fullwidth characters (and most emoji), and 1 for everything else.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+
+	case r >= 0x1100 && r <= 0x115f, // Hangul Jamo
+		r >= 0x2e80 && r <= 0x303e,   // CJK radicals, punctuation
+		r >= 0x3041 && r <= 0x33ff,   // kana, CJK symbols
+		r >= 0x3400 && r <= 0x4dbf,   // CJK extension A
+		r >= 0x4e00 && r <= 0x9fff,   // CJK unified ideographs
+		r >= 0xa000 && r <= 0xa4cf,   // Yi
+		r >= 0xac00 && r <= 0xd7a3,   // Hangul syllables
+		r >= 0xf900 && r <= 0xfaff,   // CJK compatibility ideographs
+		r >= 0xfe30 && r <= 0xfe4f,   // CJK compatibility forms
+		r >= 0xff00 && r <= 0xff60,   // fullwidth forms
+		r >= 0xffe0 && r <= 0xffe6,   // fullwidth signs
+		r >= 0x1f300 && r <= 0x1f64f, // emoji
+		r >= 0x1f900 && r <= 0x1f9ff, // more emoji
+		r >= 0x20000 && r <= 0x3fffd: // CJK extensions B and beyond
+		return 2
+
+	default:
+		return 1
+	}
+}
+
+// shellQuote returns s quoted for a POSIX shell, if it needs to be.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, needsQuote) < 0 {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// quotedOffset maps byte offset off in s to the corresponding offset in
+// shellQuote(s).
+func quotedOffset(s string, off int) int {
+	if s != "" && strings.IndexFunc(s, needsQuote) < 0 {
+		return off
+	}
+
+	return 1 + off + 3*strings.Count(s[:off], "'")
+}
+
+// needsQuote reports whether r is special to the shell.
+func needsQuote(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	case r > unicode.MaxASCII:
+		return !unicode.IsPrint(r)
+	default:
+		return !strings.ContainsRune("_-+=.,/:@%^", r)
+	}
+}
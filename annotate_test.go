@@ -0,0 +1,195 @@
+package lexopt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func annotateOk(t *testing.T, got string, expect ...string) {
+	t.Helper()
+	if want := strings.Join(expect, "\n"); got != want {
+		t.Errorf("bad annotation\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	t.Run("conversion error", func(t *testing.T) {
+		p := New([]string{"myapp", "-n", "abc"})
+		p.Next()
+		val, _ := p.Value()
+		_, err := val.Int()
+
+		annotateOk(t, p.Annotate(p.WrapErr(err), 0),
+			`"abc" (argument 2): strconv.ParseInt: parsing "abc": invalid syntax`,
+			"  myapp -n abc",
+			"           ^^^",
+		)
+	})
+
+	t.Run("unexpected value", func(t *testing.T) {
+		p := New([]string{"myapp", "-vq=yes", "file"})
+		for p.Next() {
+		}
+
+		if !errors.Is(p.Err(), ErrUnexpectedValue) {
+			t.Fatalf("expected ErrUnexpectedValue, got %v", p.Err())
+		}
+
+		annotateOk(t, p.Annotate(p.Err(), 0),
+			`"=yes" in "-vq=yes" (argument 1): unexpected value`,
+			"  myapp -vq=yes file",
+			"           ^^^^",
+		)
+	})
+
+	t.Run("missing value", func(t *testing.T) {
+		p := New([]string{"myapp", "--output"})
+		p.Next()
+		_, err := p.Value()
+
+		annotateOk(t, p.Annotate(err, 0),
+			`"--output" (argument 1): no value found`,
+			"  myapp --output",
+			"        ^^^^^^^^",
+		)
+	})
+
+	t.Run("empty value", func(t *testing.T) {
+		annotateOk(t, Annotate(&ArgError{Position{0, 3, 3, "-o="}, ErrNoValue}, "", []string{"-o="}, 0),
+			`"" in "-o=" (argument 1): no value found`,
+			"  -o=",
+			"     ^",
+		)
+	})
+
+	t.Run("quoting", func(t *testing.T) {
+		p := New([]string{"my app", "--name=it's here", "x"})
+		p.Next()
+		p.Value()
+		err := p.WrapErr(errors.New("bad name"))
+
+		annotateOk(t, p.Annotate(err, 0),
+			`"it's here" in "--name=it's here" (argument 1): bad name`,
+			`  'my app' '--name=it'\''s here' x`,
+			`                   ^^^^^^^^^^^^`,
+		)
+	})
+
+	t.Run("wide characters", func(t *testing.T) {
+		p := New([]string{"app", "--名前", "値", "-x"})
+		p.Next()
+		p.Value()
+		p.Next()
+		err := p.WrapErr(errors.New("no"))
+
+		annotateOk(t, p.Annotate(err, 0),
+			`"-x" (argument 3): no`,
+			"  app --名前 値 -x",
+			"                ^^",
+		)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		argv := []string{"aaaaaaaaaa", "bbbbbbbbbb", "--bad", "cccccccccc", "dddddddddd"}
+		err := &ArgError{Position{2, 0, 5, "--bad"}, errors.New("oops")}
+
+		annotateOk(t, Annotate(err, "myapp", argv, 30),
+			`"--bad" (argument 3): oops`,
+			"  ...bbbbbbb --bad cccccccc...",
+			"             ^^^^^",
+		)
+	})
+
+	t.Run("no position", func(t *testing.T) {
+		p := NewFromArgs([]string{"foo"})
+		annotateOk(t, p.Annotate(ErrNoValue, 80), "no value found")
+	})
+
+	t.Run("secrets", func(t *testing.T) {
+		p := New([]string{"myapp", "--token", "hunter2", "-n", "abc"})
+		p.Secrets = map[Arg]SecretPolicy{Long("token"): SecretAllowInline}
+		p.Next()
+		p.Value()
+		p.Next()
+		val, _ := p.Value()
+		_, err := val.Int()
+
+		annotateOk(t, p.Annotate(p.WrapErr(err), 0),
+			`"abc" (argument 4): strconv.ParseInt: parsing "abc": invalid syntax`,
+			"  myapp --token '[REDACTED]' -n abc",
+			"                                ^^^",
+		)
+
+		p = New([]string{"myapp", "--token=hunter2", "-v"})
+		p.Secrets = map[Arg]SecretPolicy{Long("token"): SecretAllowInline}
+		p.Next()
+		p.Next()
+
+		annotateOk(t, p.Annotate(p.Err(), 0),
+			`"=[REDACTED]" in "--token=[REDACTED]" (argument 1): unexpected value`,
+			"  myapp '--token=[REDACTED]' -v",
+			"                ^^^^^^^^^^^",
+		)
+
+		p = New([]string{"myapp", "—token=hunter2", "-n", "abc"})
+		p.Confusables = ConfusablesNormalize
+		p.Secrets = map[Arg]SecretPolicy{Long("token"): SecretAllowInline}
+		p.Next()
+		p.Value()
+		p.Next()
+		val, _ = p.Value()
+		_, err = val.Int()
+
+		annotateOk(t, p.Annotate(p.WrapErr(err), 0),
+			`"abc" (argument 3): strconv.ParseInt: parsing "abc": invalid syntax`,
+			"  myapp '—token=[REDACTED]' -n abc",
+			"                               ^^^",
+		)
+
+		p = New([]string{"myapp", "+p", "hunter2", "++token=hunter3"})
+		p.PlusOptions = true
+		p.Secrets = map[Arg]SecretPolicy{Plus('p'): SecretAllowInline, PlusLong("token"): SecretAllowInline}
+		p.Next()
+		p.Value()
+		p.Next()
+		p.Next()
+
+		annotateOk(t, p.Annotate(p.Err(), 0),
+			`"=[REDACTED]" in "++token=[REDACTED]" (argument 3): unexpected value`,
+			"  myapp +p '[REDACTED]' '++token=[REDACTED]'",
+			"                                ^^^^^^^^^^^",
+		)
+	})
+
+	t.Run("no error", func(t *testing.T) {
+		p := NewFromArgs([]string{"foo"})
+		for p.Next() {
+		}
+
+		if got := p.Annotate(p.Err(), 80); got != "" {
+			t.Errorf("want empty string for a nil error, got %q", got)
+		}
+
+		if got := Annotate(nil, "myapp", nil, 0); got != "" {
+			t.Errorf("want empty string for a nil error, got %q", got)
+		}
+	})
+}
+
+func TestWrapErr(t *testing.T) {
+	p := NewFromArgs([]string{"-n", "abc"})
+	if p.WrapErr(nil) != nil {
+		t.Error("WrapErr(nil) should return nil")
+	}
+
+	p.Next()
+	err := p.WrapErr(ErrNoValue)
+	if !errors.Is(err, ErrNoValue) {
+		t.Errorf("WrapErr broke errors.Is: %v", err)
+	}
+
+	if again := p.WrapErr(err); again != err {
+		t.Errorf("WrapErr wrapped an ArgError twice: %v", again)
+	}
+}
@@ -1,7 +1,13 @@
 package lexopt
 
 import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math/bits"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,6 +18,9 @@ const (
 	argShort
 	argLong
 	argPlain
+	argPlus
+	argPlusLong
+	argNumber
 )
 
 // Arg represents a command line argument. Arg has methods for parsing into
@@ -33,6 +42,28 @@ func Long(toMatch string) Arg {
 	return Arg{argLong, toMatch}
 }
 
+// Plus represents a short option introduced with a plus sign instead of a
+// dash, as in set +e. Plus options are only produced when
+// [Parser.PlusOptions] is set.
+func Plus(toMatch rune) Arg {
+	return Arg{argPlus, string(toMatch)}
+}
+
+// PlusLong represents a long option introduced with a double plus, as in
+// ++name. Like [Plus], it is only produced when [Parser.PlusOptions] is set.
+func PlusLong(toMatch string) Arg {
+	return Arg{argPlusLong, toMatch}
+}
+
+// Number represents a numeric option, like the -20 in head -20. Number options
+// are only produced when [Parser.NumberOptions] is set; n should not be
+// negative. Leading zeros are not significant, so -007 matches Number(7). The
+// conversion methods work as expected on a number option, so
+// parser.Current.Int() returns its value.
+func Number(n int) Arg {
+	return Arg{argNumber, strconv.Itoa(n)}
+}
+
 // Value represents a value argument. This could be a positional argument
 // (obtained with [Parser.Next]) or an option value (obtained with
 // [Parser.Value]).
@@ -53,15 +84,25 @@ func (a Arg) String() string {
 // all the other Must methods.
 func (a Arg) MustString() string { return a.String() }
 
-// DashedString returns a formatted version of Arg. Short options are preceded
-// with a single dash, long options with a double dash, and all other args as
-// the raw arg value.
+// IsOption returns true if the Arg is an option of any kind, and false if it
+// is a value.
+func (a Arg) IsOption() bool {
+	return a.kind != argPlain && a.kind != argInvalid
+}
+
+// DashedString returns a formatted version of Arg. Short options and numbers
+// are preceded with a single dash, long options with a double dash, plus
+// options with their plus signs, and all other args as the raw arg value.
 func (a Arg) DashedString() string {
 	switch a.kind {
-	case argShort:
+	case argShort, argNumber:
 		return "-" + a.s
 	case argLong:
 		return "--" + a.s
+	case argPlus:
+		return "+" + a.s
+	case argPlusLong:
+		return "++" + a.s
 	default:
 		return a.s
 	}
@@ -75,18 +116,47 @@ func (a Arg) Bool() (bool, error) {
 // MustBool is like [Arg.Bool], but panics if the conversion fails.
 func (a Arg) MustBool() bool { return must(a.Bool()) }
 
-// Bool converts Arg to an int, using [strconv.ParseInt].
+// Int converts Arg to an int, using [strconv.ParseInt]. Like all the integer
+// conversions, it returns a [RangeError] wrapping the [*strconv.NumError] if
+// the value doesn't fit.
 func (a Arg) Int() (int, error) {
-	val, err := strconv.ParseInt(a.s, 10, strconv.IntSize)
+	val, err := parseInt(a.s, 10, strconv.IntSize, "int")
 	return int(val), err
 }
 
 // MustInt is like [Arg.Int], but panics if the conversion fails.
 func (a Arg) MustInt() int { return must(a.Int()) }
 
+// Int8 converts Arg to an int8, using [strconv.ParseInt].
+func (a Arg) Int8() (int8, error) {
+	val, err := parseInt(a.s, 10, 8, "int8")
+	return int8(val), err
+}
+
+// MustInt8 is like [Arg.Int8], but panics if the conversion fails.
+func (a Arg) MustInt8() int8 { return must(a.Int8()) }
+
+// Int16 converts Arg to an int16, using [strconv.ParseInt].
+func (a Arg) Int16() (int16, error) {
+	val, err := parseInt(a.s, 10, 16, "int16")
+	return int16(val), err
+}
+
+// MustInt16 is like [Arg.Int16], but panics if the conversion fails.
+func (a Arg) MustInt16() int16 { return must(a.Int16()) }
+
+// Int32 converts Arg to an int32, using [strconv.ParseInt].
+func (a Arg) Int32() (int32, error) {
+	val, err := parseInt(a.s, 10, 32, "int32")
+	return int32(val), err
+}
+
+// MustInt32 is like [Arg.Int32], but panics if the conversion fails.
+func (a Arg) MustInt32() int32 { return must(a.Int32()) }
+
 // Int64 converts Arg to an int64, using [strconv.ParseInt].
 func (a Arg) Int64() (int64, error) {
-	return strconv.ParseInt(a.s, 10, 64)
+	return parseInt(a.s, 10, 64, "int64")
 }
 
 // MustInt64 is like [Arg.Int64], but panics if the conversion fails.
@@ -94,21 +164,88 @@ func (a Arg) MustInt64() int64 { return must(a.Int64()) }
 
 // Uint converts Arg to a uint, using [strconv.ParseUint].
 func (a Arg) Uint() (uint, error) {
-	val, err := strconv.ParseUint(a.s, 10, strconv.IntSize)
+	val, err := parseUint(a.s, 10, strconv.IntSize, "uint")
 	return uint(val), err
 }
 
 // MustUint is like [Arg.Uint], but panics if the conversion fails.
 func (a Arg) MustUint() uint { return must(a.Uint()) }
 
+// Uint8 converts Arg to a uint8, using [strconv.ParseUint].
+func (a Arg) Uint8() (uint8, error) {
+	val, err := parseUint(a.s, 10, 8, "uint8")
+	return uint8(val), err
+}
+
+// MustUint8 is like [Arg.Uint8], but panics if the conversion fails.
+func (a Arg) MustUint8() uint8 { return must(a.Uint8()) }
+
+// Uint16 converts Arg to a uint16, using [strconv.ParseUint].
+func (a Arg) Uint16() (uint16, error) {
+	val, err := parseUint(a.s, 10, 16, "uint16")
+	return uint16(val), err
+}
+
+// MustUint16 is like [Arg.Uint16], but panics if the conversion fails.
+func (a Arg) MustUint16() uint16 { return must(a.Uint16()) }
+
+// Uint32 converts Arg to a uint32, using [strconv.ParseUint].
+func (a Arg) Uint32() (uint32, error) {
+	val, err := parseUint(a.s, 10, 32, "uint32")
+	return uint32(val), err
+}
+
+// MustUint32 is like [Arg.Uint32], but panics if the conversion fails.
+func (a Arg) MustUint32() uint32 { return must(a.Uint32()) }
+
 // Uint64 converts Arg to a uint64, using [strconv.ParseUint].
 func (a Arg) Uint64() (uint64, error) {
-	return strconv.ParseUint(a.s, 10, 64)
+	return parseUint(a.s, 10, 64, "uint64")
 }
 
 // MustUint64 is like [Arg.Uint64], but panics if the conversion fails.
 func (a Arg) MustUint64() uint64 { return must(a.Uint64()) }
 
+// IntAuto is like [Arg.Int], but the base is implied by the value's prefix,
+// as for Go integer literals: 0x for hexadecimal, 0o or 0 for octal, and 0b
+// for binary. Underscores may be used as digit separators, as in 1_000_000.
+// Note that this means a leading zero makes the value octal: 0755 is 493.
+func (a Arg) IntAuto() (int, error) {
+	val, err := parseInt(a.s, 0, strconv.IntSize, "int")
+	return int(val), err
+}
+
+// MustIntAuto is like [Arg.IntAuto], but panics if the conversion fails.
+func (a Arg) MustIntAuto() int { return must(a.IntAuto()) }
+
+// Int64Auto is like [Arg.Int64], but the base is implied by the value's
+// prefix, as for [Arg.IntAuto].
+func (a Arg) Int64Auto() (int64, error) {
+	return parseInt(a.s, 0, 64, "int64")
+}
+
+// MustInt64Auto is like [Arg.Int64Auto], but panics if the conversion fails.
+func (a Arg) MustInt64Auto() int64 { return must(a.Int64Auto()) }
+
+// UintAuto is like [Arg.Uint], but the base is implied by the value's prefix,
+// as for [Arg.IntAuto].
+func (a Arg) UintAuto() (uint, error) {
+	val, err := parseUint(a.s, 0, strconv.IntSize, "uint")
+	return uint(val), err
+}
+
+// MustUintAuto is like [Arg.UintAuto], but panics if the conversion fails.
+func (a Arg) MustUintAuto() uint { return must(a.UintAuto()) }
+
+// Uint64Auto is like [Arg.Uint64], but the base is implied by the value's
+// prefix, as for [Arg.IntAuto].
+func (a Arg) Uint64Auto() (uint64, error) {
+	return parseUint(a.s, 0, 64, "uint64")
+}
+
+// MustUint64Auto is like [Arg.Uint64Auto], but panics if the conversion fails.
+func (a Arg) MustUint64Auto() uint64 { return must(a.Uint64Auto()) }
+
 // Float64 converts Arg to a float64, using [strconv.ParseFloat].
 func (a Arg) Float64() (float64, error) {
 	return strconv.ParseFloat(a.s, 64)
@@ -117,6 +254,116 @@ func (a Arg) Float64() (float64, error) {
 // MustFloat64 is like [Arg.Float64], but panics if the conversion fails.
 func (a Arg) MustFloat64() float64 { return must(a.Float64()) }
 
+// Bytes converts Arg to a number of bytes, accepting a size with an optional
+// unit, like 512MiB or 1.5G. Units are case-insensitive, and may be SI
+// (kB, MB, GB, TB, PB, EB: powers of 1000) or IEC (KiB, MiB, GiB, TiB, PiB,
+// EiB: powers of 1024). As with GNU tools, a bare K, M, G and so on means
+// the IEC unit, and B on its own means bytes. The number may have a
+// fractional part, in which case any fraction of a byte is dropped. Bytes
+// returns a [RangeError] if the size doesn't fit in a uint64.
+func (a Arg) Bytes() (uint64, error) {
+	syntaxErr := &strconv.NumError{Func: "Bytes", Num: a.s, Err: strconv.ErrSyntax}
+
+	num := a.s
+	unit := strings.TrimLeft(num, "0123456789.")
+	num = strings.TrimSpace(num[:len(num)-len(unit)])
+	unit = strings.TrimSpace(unit)
+
+	whole, frac, _ := strings.Cut(num, ".")
+	if whole == "" && frac == "" || strings.Contains(frac, ".") {
+		return 0, syntaxErr
+	}
+
+	mult, ok := byteUnits[strings.ToLower(unit)]
+	if !ok {
+		return 0, syntaxErr
+	}
+
+	rangeErr := &RangeError{Value: a.s, Type: "uint64"}
+
+	var w uint64
+	if whole != "" {
+		var err error
+		if w, err = strconv.ParseUint(whole, 10, 64); err != nil {
+			return 0, rangeErr
+		}
+	}
+
+	hi, total := bits.Mul64(w, mult)
+	if hi != 0 {
+		return 0, rangeErr
+	}
+
+	// The fraction is at most 19 digits, so that its denominator fits in a
+	// uint64; any more are too small to matter.
+	if len(frac) > 19 {
+		frac = frac[:19]
+	}
+
+	if frac != "" {
+		f, _ := strconv.ParseUint(frac, 10, 64)
+		denom := uint64(1)
+		for i := 0; i < len(frac); i++ {
+			denom *= 10
+		}
+
+		// f/denom < 1, so f*mult/denom < mult, and the division can't overflow.
+		hi, lo := bits.Mul64(f, mult)
+		fracBytes, _ := bits.Div64(hi, lo, denom)
+
+		var carry uint64
+		total, carry = bits.Add64(total, fracBytes, 0)
+		if carry != 0 {
+			return 0, rangeErr
+		}
+	}
+
+	return total, nil
+}
+
+// MustBytes is like [Arg.Bytes], but panics if the conversion fails.
+func (a Arg) MustBytes() uint64 { return must(a.Bytes()) }
+
+// byteUnits maps lower-cased size units to their multipliers.
+var byteUnits = map[string]uint64{
+	"": 1, "b": 1,
+	"k": 1 << 10, "ki": 1 << 10, "kib": 1 << 10, "kb": 1e3,
+	"m": 1 << 20, "mi": 1 << 20, "mib": 1 << 20, "mb": 1e6,
+	"g": 1 << 30, "gi": 1 << 30, "gib": 1 << 30, "gb": 1e9,
+	"t": 1 << 40, "ti": 1 << 40, "tib": 1 << 40, "tb": 1e12,
+	"p": 1 << 50, "pi": 1 << 50, "pib": 1 << 50, "pb": 1e15,
+	"e": 1 << 60, "ei": 1 << 60, "eib": 1 << 60, "eb": 1e18,
+}
+
+// FormatBytes formats a number of bytes for help text and defaults, using
+// the largest IEC unit that gives an exact result with at most two decimal
+// places, like 512MiB, 1.5GiB or 1000B. The result can be read back with
+// [Arg.Bytes].
+func FormatBytes(n uint64) string {
+	units := []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+	for i := len(units) - 1; i >= 0; i-- {
+		size := uint64(1) << (10 * (i + 1))
+		if n < size {
+			continue
+		}
+
+		whole, rem := n/size, n%size
+		if rem == 0 {
+			return fmt.Sprintf("%d%s", whole, units[i])
+		}
+
+		// Since size is a power of 2, rem/size has at most two decimal places
+		// exactly when it is a multiple of 1/4.
+		if quarter := size / 4; rem%quarter == 0 {
+			hundredths := fmt.Sprintf("%02d", rem/quarter*25)
+			return fmt.Sprintf("%d.%s%s", whole, strings.TrimRight(hundredths, "0"), units[i])
+		}
+	}
+
+	return fmt.Sprintf("%dB", n)
+}
+
 // Duration converts Arg to a [time.Duration], using [time.ParseDuration].
 func (a Arg) Duration() (time.Duration, error) {
 	return time.ParseDuration(a.s)
@@ -125,6 +372,279 @@ func (a Arg) Duration() (time.Duration, error) {
 // MustDuration is like [Arg.Duration], but panics if the conversion fails.
 func (a Arg) MustDuration() time.Duration { return must(a.Duration()) }
 
+// IntRange is an inclusive range of integers, from Lo to Hi, every Step
+// apart, as returned by [Arg.IntRanges]. Hi is always Lo plus a multiple of
+// Step. A Step of zero, as in IntRange{Lo: 1, Hi: 3}, means 1.
+type IntRange struct {
+	Lo, Hi int
+	Step   int
+}
+
+// Contains reports whether n is in the range.
+func (r IntRange) Contains(n int) bool {
+	return n >= r.Lo && n <= r.Hi && (n-r.Lo)%r.step() == 0
+}
+
+// Values returns the numbers in the range, in order.
+func (r IntRange) Values() []int {
+	if r.Hi < r.Lo {
+		return nil
+	}
+
+	step := r.step()
+	vals := make([]int, 0, (r.Hi-r.Lo)/step+1)
+	for n := r.Lo; n <= r.Hi; n += step {
+		vals = append(vals, n)
+		if n > r.Hi-step {
+			break // don't overflow at the top of the int range
+		}
+	}
+	return vals
+}
+
+// step returns r.Step, or 1 if it isn't positive, as in a range made by hand.
+func (r IntRange) step() int {
+	if r.Step <= 0 {
+		return 1
+	}
+	return r.Step
+}
+
+// String returns the range in the syntax that IntRanges accepts, like 8,
+// 0-3 or 0-90:10.
+func (r IntRange) String() string {
+	switch {
+	case r.Lo == r.Hi:
+		return strconv.Itoa(r.Lo)
+	case r.step() == 1:
+		return fmt.Sprintf("%d-%d", r.Lo, r.Hi)
+	default:
+		return fmt.Sprintf("%d-%d:%d", r.Lo, r.Hi, r.Step)
+	}
+}
+
+// IntRanges converts Arg to a list of ranges of non-negative integers, as for
+// --cpus 0-3,8,10-11 or --ports 8000-8100. Each comma-separated element is a
+// number (8), a range (0-3), an open-ended range that goes up to hi (5-), or
+// a range with a step (0-100:10, meaning 0, 10, ..., 100).
+//
+// Every number must be between lo and hi, inclusive; if not, IntRanges
+// returns a [ParseError] that wraps [strconv.ErrRange]. The result is
+// normalized: the ranges are sorted, and overlapping or adjacent ranges
+// without a step are merged, so 3,0-2,2-4 becomes 0-4. Ranges with a step
+// are kept as they are, except that Hi is lowered to the last number in the
+// range.
+func (a Arg) IntRanges(lo, hi int) ([]IntRange, error) {
+	syntaxErr := func(elem string) error {
+		return &ParseError{Value: elem, Want: "numbers and ranges, like 0-3,8,10-11 or 0-100:10"}
+	}
+
+	parse := func(elem, s string) (int, error) {
+		if s == "" || strings.Trim(s, "0123456789") != "" {
+			return 0, syntaxErr(elem)
+		}
+
+		n, err := strconv.Atoi(s)
+		if err != nil || n < lo || n > hi {
+			return 0, &ParseError{Value: elem, Want: fmt.Sprintf("numbers from %d to %d", lo, hi), Err: strconv.ErrRange}
+		}
+		return n, nil
+	}
+
+	if a.s == "" {
+		return nil, syntaxErr(a.s)
+	}
+
+	var ranges []IntRange
+	for _, elem := range strings.Split(a.s, ",") {
+		spec, stepStr, hasStep := strings.Cut(elem, ":")
+		first, last, isRange := strings.Cut(spec, "-")
+
+		r := IntRange{Step: 1}
+		var err error
+		if r.Lo, err = parse(elem, first); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case !isRange:
+			if hasStep {
+				return nil, syntaxErr(elem)
+			}
+			r.Hi = r.Lo
+		case last == "":
+			r.Hi = hi
+		default:
+			if r.Hi, err = parse(elem, last); err != nil {
+				return nil, err
+			}
+		}
+
+		if r.Hi < r.Lo {
+			return nil, &ParseError{Value: elem, Want: "a range from low to high"}
+		}
+
+		if hasStep {
+			if strings.Trim(stepStr, "0123456789") != "" {
+				return nil, syntaxErr(elem)
+			}
+			if r.Step, err = strconv.Atoi(stepStr); err != nil || r.Step < 1 {
+				return nil, syntaxErr(elem)
+			}
+
+			r.Hi -= (r.Hi - r.Lo) % r.Step
+			if r.Lo == r.Hi {
+				r.Step = 1
+			}
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	return normalizeRanges(ranges), nil
+}
+
+// MustIntRanges is like [Arg.IntRanges], but panics if the conversion fails.
+func (a Arg) MustIntRanges(lo, hi int) []IntRange { return must(a.IntRanges(lo, hi)) }
+
+// normalizeRanges sorts ranges and merges the ones without a step that
+// overlap or touch, as described for IntRanges.
+func normalizeRanges(ranges []IntRange) []IntRange {
+	slices.SortFunc(ranges, func(a, b IntRange) int {
+		if c := cmp.Compare(a.Lo, b.Lo); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Hi, b.Hi); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Step, b.Step)
+	})
+
+	out := ranges[:0]
+	for _, r := range ranges {
+		if len(out) > 0 {
+			prev := &out[len(out)-1]
+			if *prev == r {
+				continue
+			}
+
+			if prev.Step == 1 && r.Step == 1 && r.Lo-1 <= prev.Hi {
+				prev.Hi = max(prev.Hi, r.Hi)
+				continue
+			}
+		}
+
+		out = append(out, r)
+	}
+
+	return out
+}
+
+// IntSet is like [Arg.IntRanges], but returns every number in the ranges,
+// sorted and without duplicates. Beware of open-ended ranges if hi is large.
+func (a Arg) IntSet(lo, hi int) ([]int, error) {
+	ranges, err := a.IntRanges(lo, hi)
+	if err != nil {
+		return nil, err
+	}
+
+	var set []int
+	for _, r := range ranges {
+		set = append(set, r.Values()...)
+	}
+
+	slices.Sort(set)
+	return slices.Compact(set), nil
+}
+
+// MustIntSet is like [Arg.IntSet], but panics if the conversion fails.
+func (a Arg) MustIntSet(lo, hi int) []int { return must(a.IntSet(lo, hi)) }
+
+// Shard converts Arg to a shard number and count, as for --shard 2/5, meaning
+// the second of five shards. The index is 1-based, so it must be between 1
+// and count.
+func (a Arg) Shard() (index, count int, err error) {
+	want := "a shard, like 2/5, numbered from 1"
+
+	i, n, ok := strings.Cut(a.s, "/")
+	if !ok || i == "" || n == "" || strings.Trim(i+n, "0123456789") != "" {
+		return 0, 0, &ParseError{Value: a.s, Want: want}
+	}
+
+	index, ierr := strconv.Atoi(i)
+	count, nerr := strconv.Atoi(n)
+	if ierr != nil || nerr != nil || count < 1 || index < 1 || index > count {
+		return 0, 0, &ParseError{Value: a.s, Want: want, Err: strconv.ErrRange}
+	}
+
+	return index, count, nil
+}
+
+// ParseError is returned by conversions when a value is not in the expected
+// format, for conversions that don't have a more specific error.
+type ParseError struct {
+	Value string // the value given
+	Want  string // a description of what was expected
+	Err   error  // the underlying error, if any
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("invalid value %q: want %s", e.Value, e.Want)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// RangeError is returned by the integer conversions when a value is too large
+// or too small for the type it's being converted to. It wraps the
+// [*strconv.NumError] from the conversion, if there was one, and
+// [strconv.ErrRange] in any case.
+type RangeError struct {
+	Value string // the value given
+	Type  string // the type it was being converted to, like "int8"
+	Err   error  // the underlying error, if any
+}
+
+func (e *RangeError) Error() string {
+	msg := fmt.Sprintf("%q is out of range for %s", e.Value, e.Type)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *RangeError) Unwrap() error {
+	if e.Err != nil {
+		return e.Err
+	}
+	return strconv.ErrRange
+}
+
+// parseInt is [strconv.ParseInt], but with a RangeError naming typ if the
+// value doesn't fit.
+func parseInt(s string, base, bits int, typ string) (int64, error) {
+	val, err := strconv.ParseInt(s, base, bits)
+	if errors.Is(err, strconv.ErrRange) {
+		err = &RangeError{Value: s, Type: typ, Err: err}
+	}
+	return val, err
+}
+
+// parseUint is [strconv.ParseUint], but with a RangeError naming typ if the
+// value doesn't fit.
+func parseUint(s string, base, bits int, typ string) (uint64, error) {
+	val, err := strconv.ParseUint(s, base, bits)
+	if errors.Is(err, strconv.ErrRange) {
+		err = &RangeError{Value: s, Type: typ, Err: err}
+	}
+	return val, err
+}
+
 func must[T any](val T, err error) T {
 	if err != nil {
 		panic(err)
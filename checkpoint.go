@@ -0,0 +1,51 @@
+package lexopt
+
+// A Checkpoint is an opaque snapshot of a parser's progress through its
+// arguments, made by [Parser.Checkpoint] and used by [Parser.Restore].
+type Checkpoint struct {
+	parser  *Parser
+	current Arg
+	cursor  cursor
+}
+
+// Checkpoint takes a snapshot of the parser's progress, including Current
+// and any errors seen so far. Passing it to [Parser.Restore] rewinds the
+// parser to exactly this point, which is useful for speculative parsing:
+// trying to interpret the upcoming arguments one way and backing off if that
+// fails. The parser's configuration (like [Parser.PlusOptions]) is not part
+// of the snapshot.
+func (p *Parser) Checkpoint() Checkpoint {
+	return Checkpoint{parser: p, current: p.Current, cursor: p.snapshot()}
+}
+
+// Restore rewinds the parser to cp, which must have been made by this
+// parser's Checkpoint method. A checkpoint can be restored any number of
+// times. Errors recorded after the checkpoint was made are forgotten.
+func (p *Parser) Restore(cp Checkpoint) {
+	if cp.parser != p {
+		panic("lexopt: Restore called with a Checkpoint from another parser")
+	}
+
+	p.Current = cp.current
+	p.cursor = cp.cursor
+}
+
+// Clone returns an independent copy of the parser, with the same
+// configuration and progress. Advancing the clone has no effect on the
+// original, and vice versa.
+func (p *Parser) Clone() *Parser {
+	clone := *p
+	clone.cursor = p.snapshot()
+	return &clone
+}
+
+// snapshot returns a copy of the parser's cursor that is safe to keep while
+// the parser carries on. The only state that is ever modified in place is the
+// errs slice, which is appended to, so its capacity is clipped to make sure
+// that appends made after the snapshot never write into the snapshot's
+// backing array.
+func (p *Parser) snapshot() cursor {
+	c := p.cursor
+	c.errs = c.errs[:len(c.errs):len(c.errs)]
+	return c
+}
@@ -0,0 +1,114 @@
+package lexopt
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// transcript drains p, returning a description of everything it yields.
+func transcript(p *Parser) string {
+	var out []string
+	for p.Next() {
+		out = append(out, fmt.Sprintf("%s@%d:%d-%d", p.Current.DashedString(), p.Position().Index, p.Position().Start, p.Position().End))
+	}
+
+	out = append(out, fmt.Sprint(p.Err()))
+	return strings.Join(out, " ")
+}
+
+func TestCheckpoint(t *testing.T) {
+	tests := []struct {
+		desc  string
+		argv  string
+		steps int // calls to Next before taking the checkpoint
+	}{
+		{"at start", "-abc --x=y -- -q", 0},
+		{"mid-cluster", "-abc --x=y -- -q", 1},
+		{"pending long value", "-abc --x=y -- -q", 4},
+		{"pending short value", "-ab=c -d", 2},
+		{"after double dash", "-abc --x=y -- -q r", 5},
+		{"at end", "-a", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			p := NewFromArgs(strings.Fields(test.argv))
+			p.CollectErrors = true
+			for i := 0; i < test.steps; i++ {
+				if !p.Next() {
+					t.Fatalf("ran out of args after %d steps", i)
+				}
+			}
+
+			cp := p.Checkpoint()
+			saved := *p
+			saved.cursor = p.snapshot()
+
+			first := transcript(p)
+			p.Restore(cp)
+
+			if p.Current != saved.Current || !reflect.DeepEqual(p.cursor, saved.cursor) {
+				t.Errorf("restore was not exact:\nwant %+v\ngot  %+v", saved.cursor, p.cursor)
+			}
+
+			if second := transcript(p); second != first {
+				t.Errorf("different results after restore:\nfirst:  %s\nsecond: %s", first, second)
+			}
+		})
+	}
+
+	t.Run("forgets errors", func(t *testing.T) {
+		p := NewFromArgs([]string{"--x=y", "--z=w", "-q"})
+		p.CollectErrors = true
+		p.Next()
+		cp := p.Checkpoint()
+
+		for p.Next() {
+		}
+		if p.Err() == nil {
+			t.Fatal("expected an error")
+		}
+
+		p.Restore(cp)
+		if p.Err() != nil {
+			t.Errorf("error survived restore: %v", p.Err())
+		}
+	})
+
+	t.Run("wrong parser", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic")
+			}
+		}()
+
+		p := NewFromArgs(nil)
+		p.Restore(Checkpoint{})
+	})
+}
+
+func TestClone(t *testing.T) {
+	p := NewFromArgs([]string{"-abc", "--x=y", "--z=w", "file"})
+	p.CollectErrors = true
+	p.Next()
+	p.AddErr(fmt.Errorf("first"))
+
+	clone := p.Clone()
+	cloneResult := transcript(clone)
+	origResult := transcript(p)
+
+	if cloneResult != origResult {
+		t.Errorf("clone differs:\norig:  %s\nclone: %s", origResult, cloneResult)
+	}
+
+	if !strings.Contains(origResult, "first") {
+		t.Errorf("original lost its error: %s", origResult)
+	}
+
+	p.AddErr(fmt.Errorf("only in original"))
+	if strings.Contains(clone.Err().Error(), "only in original") {
+		t.Errorf("errors leaked into clone: %v", clone.Err())
+	}
+}
@@ -0,0 +1,193 @@
+package lexopt
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ChoiceError is returned when a value is not one of a fixed set of choices.
+type ChoiceError struct {
+	Value      string   // the value given
+	Choices    []string // the valid choices
+	Suggestion string   // the closest valid choice, if any is close
+	Ambiguous  []string // if Value is an ambiguous prefix, what it could mean
+}
+
+func (e *ChoiceError) Error() string {
+	if len(e.Ambiguous) > 0 {
+		return fmt.Sprintf("ambiguous value %q: could be %s", e.Value, strings.Join(e.Ambiguous, ", "))
+	}
+
+	msg := fmt.Sprintf("invalid value %q: must be one of %s", e.Value, strings.Join(e.Choices, ", "))
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", e.Suggestion)
+	}
+
+	return msg
+}
+
+// OneOf returns the argument's value if it is exactly one of choices, and a
+// [ChoiceError] otherwise. For case-insensitive matching, prefixes or
+// aliases, use a [Choice].
+func (a Arg) OneOf(choices ...string) (string, error) {
+	return Choice[string]{Choices: choices}.Parse(a)
+}
+
+// MustOneOf is like [Arg.OneOf], but panics if the value is not valid.
+func (a Arg) MustOneOf(choices ...string) string { return must(a.OneOf(choices...)) }
+
+// Choice is a set of valid values for an argument, like the json, yaml and
+// table of --format. T is usually a string type of your own, with a
+// constant for each choice:
+//
+//	type Format string
+//	var formats = lexopt.Choice[Format]{Choices: []Format{"json", "yaml", "table"}}
+//	format, err := formats.Parse(val)
+type Choice[T ~string] struct {
+	// Choices are the valid values, in the order they should be listed in
+	// error messages and help text.
+	Choices []T
+
+	// Aliases are alternative spellings, each of which stands for one of the
+	// Choices, like "yml" for "yaml".
+	Aliases map[string]T
+
+	// FoldCase makes matching case-insensitive.
+	FoldCase bool
+
+	// Prefix allows any unambiguous prefix of a choice or alias, like "t" for
+	// "table".
+	Prefix bool
+}
+
+// Parse returns the choice that a matches, or a [ChoiceError] that lists the
+// valid choices and suggests the nearest one.
+func (c Choice[T]) Parse(a Arg) (T, error) {
+	val := c.fold(a.s)
+
+	for _, choice := range c.Choices {
+		if c.fold(string(choice)) == val {
+			return choice, nil
+		}
+	}
+
+	for alias, choice := range c.Aliases {
+		if c.fold(alias) == val {
+			return choice, nil
+		}
+	}
+
+	if c.Prefix && val != "" {
+		var matches []T
+		var names []string
+		for _, name := range c.Completions() {
+			if strings.HasPrefix(c.fold(name), val) {
+				choice := c.lookup(name)
+				if !slices.Contains(matches, choice) {
+					matches = append(matches, choice)
+					names = append(names, string(choice))
+				}
+			}
+		}
+
+		switch len(matches) {
+		case 1:
+			return matches[0], nil
+		case 0:
+			// fall through to the error below
+		default:
+			return "", &ChoiceError{Value: a.s, Choices: c.Names(), Ambiguous: names}
+		}
+	}
+
+	return "", &ChoiceError{Value: a.s, Choices: c.Names(), Suggestion: c.suggest(val)}
+}
+
+// MustParse is like [Choice.Parse], but panics if a is not valid.
+func (c Choice[T]) MustParse(a Arg) T { return must(c.Parse(a)) }
+
+// Names returns the choices as strings, for help text.
+func (c Choice[T]) Names() []string {
+	names := make([]string, len(c.Choices))
+	for i, choice := range c.Choices {
+		names[i] = string(choice)
+	}
+	return names
+}
+
+// Completions returns everything that Parse accepts in full, the choices
+// followed by the aliases in sorted order, for shell completion.
+func (c Choice[T]) Completions() []string {
+	aliases := make([]string, 0, len(c.Aliases))
+	for alias := range c.Aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	return append(c.Names(), aliases...)
+}
+
+// fold folds the case of s, if the Choice is case-insensitive.
+func (c Choice[T]) fold(s string) string {
+	if c.FoldCase {
+		return strings.ToLower(s)
+	}
+	return s
+}
+
+// lookup returns the choice that name, a choice or an alias, stands for.
+func (c Choice[T]) lookup(name string) T {
+	if choice, ok := c.Aliases[name]; ok {
+		return choice
+	}
+	return T(name)
+}
+
+// suggest returns the choice closest to val, or the empty string if nothing
+// is close enough to be a plausible typo.
+func (c Choice[T]) suggest(val string) string {
+	best, bestDist := "", max(1, len(val)/3)
+	for _, name := range c.Completions() {
+		if dist := editDistance(val, c.fold(name)); dist <= bestDist && (best == "" || dist < bestDist) {
+			best, bestDist = string(c.lookup(name)), dist
+		}
+	}
+
+	return best
+}
+
+// editDistance returns the edit distance between a and b, counted in code
+// points, where an edit is an insertion, deletion, substitution or
+// transposition of adjacent characters (the "optimal string alignment"
+// distance).
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// d[i][j] is the distance between ra[:i] and rb[:j].
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
@@ -0,0 +1,103 @@
+package lexopt
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func choiceErrOk(t *testing.T, err error) *ChoiceError {
+	t.Helper()
+
+	var cerr *ChoiceError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("expected *ChoiceError, got %v", err)
+	}
+
+	return cerr
+}
+
+func TestOneOf(t *testing.T) {
+	a := Value("yaml")
+	runConvOk(t, a, "valid", "yaml", func() (string, error) { return a.OneOf("json", "yaml") }, func() string { return a.MustOneOf("json", "yaml") })
+
+	a = Value("YAML")
+	runConvErr(t, a, "case matters", func() (string, error) { return a.OneOf("json", "yaml") }, func() string { return a.MustOneOf("json", "yaml") })
+
+	_, err := Value("jsno").OneOf("json", "yaml", "table")
+	cerr := choiceErrOk(t, err)
+	if cerr.Suggestion != "json" {
+		t.Errorf("bad suggestion: want %q, got %q", "json", cerr.Suggestion)
+	}
+
+	if msg := err.Error(); msg != `invalid value "jsno": must be one of json, yaml, table (did you mean "json"?)` {
+		t.Errorf("bad error message: %s", msg)
+	}
+
+	_, err = Value("xml").OneOf("json", "yaml", "table")
+	if cerr := choiceErrOk(t, err); cerr.Suggestion != "" {
+		t.Errorf("unexpected suggestion: %q", cerr.Suggestion)
+	}
+}
+
+type format string
+
+func TestChoice(t *testing.T) {
+	formats := Choice[format]{
+		Choices:  []format{"json", "yaml", "table", "text"},
+		Aliases:  map[string]format{"yml": "yaml", "tsv": "table"},
+		FoldCase: true,
+		Prefix:   true,
+	}
+
+	good := map[string]format{
+		"json":  "json",
+		"JSON":  "json",
+		"yml":   "yaml",
+		"YmL":   "yaml",
+		"j":     "json",
+		"y":     "yaml", // both yaml and yml, but they're the same thing
+		"tab":   "table",
+		"ts":    "table",
+		"te":    "text",
+		"table": "table",
+	}
+
+	for in, expect := range good {
+		if got, err := formats.Parse(Value(in)); err != nil || got != expect {
+			t.Errorf("Parse(%q): want %q, got %q, %v", in, expect, got, err)
+		}
+	}
+
+	_, err := formats.Parse(Value("t"))
+	cerr := choiceErrOk(t, err)
+	if expect := []string{"table", "text"}; !reflect.DeepEqual(cerr.Ambiguous, expect) {
+		t.Errorf("bad ambiguous list: want %q, got %q", expect, cerr.Ambiguous)
+	}
+
+	_, err = formats.Parse(Value("jason"))
+	if cerr := choiceErrOk(t, err); cerr.Suggestion != "json" {
+		t.Errorf("bad suggestion: want %q, got %q", "json", cerr.Suggestion)
+	}
+
+	_, err = formats.Parse(Value("ymll"))
+	if cerr := choiceErrOk(t, err); cerr.Suggestion != "yaml" {
+		t.Errorf("alias suggestion should be canonical: got %q", cerr.Suggestion)
+	}
+
+	strict := Choice[format]{Choices: formats.Choices}
+	if _, err := strict.Parse(Value("j")); err == nil {
+		t.Error("prefix matched without Prefix")
+	}
+	if _, err := strict.Parse(Value("JSON")); err == nil {
+		t.Error("case folded without FoldCase")
+	}
+
+	if names := formats.Names(); !reflect.DeepEqual(names, []string{"json", "yaml", "table", "text"}) {
+		t.Errorf("bad Names: %q", names)
+	}
+
+	if comps := formats.Completions(); !reflect.DeepEqual(comps, []string{"json", "yaml", "table", "text", "tsv", "yml"}) {
+		t.Errorf("bad Completions: %q", comps)
+	}
+}
@@ -0,0 +1,107 @@
+package lexopt
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ConfusableMode controls how a [Parser] treats tokens that start with a
+// Unicode character that looks like a dash, as happens when a command line
+// is pasted from a word processor or chat client that "helpfully" turned
+// --verbose into —verbose.
+type ConfusableMode int
+
+const (
+	// ConfusablesIgnore treats such tokens like any other; usually that means
+	// they become positional arguments. This is the default.
+	ConfusablesIgnore ConfusableMode = iota
+
+	// ConfusablesReject makes [Parser.Next] fail with a [ConfusableError]
+	// that suggests the ASCII spelling.
+	ConfusablesReject
+
+	// ConfusablesNormalize silently replaces the lookalike characters with
+	// their ASCII equivalents and parses the result.
+	ConfusablesNormalize
+)
+
+// ConfusableError is returned by [Parser.Err] when [Parser.Confusables] is
+// ConfusablesReject and the parser sees a token starting with a Unicode dash.
+type ConfusableError struct {
+	Token      string // the token as given on the command line
+	Suggestion string // what the user probably meant
+}
+
+func (e *ConfusableError) Error() string {
+	return fmt.Sprintf("%q starts with a Unicode dash; did you mean %q?", e.Token, e.Suggestion)
+}
+
+// dashes maps dash lookalikes to the ASCII they most likely stood for. The en
+// dash is absent, because it is handled specially by fixConfusable.
+var dashes = map[rune]string{
+	'-':      "-",
+	'\u2010': "-",  // hyphen
+	'\u2011': "-",  // non-breaking hyphen
+	'\u2012': "-",  // figure dash
+	'\u2014': "--", // em dash
+	'\u2015': "--", // horizontal bar
+	'\u2212': "-",  // minus sign
+	'\ufe63': "-",  // small hyphen-minus
+	'\uff0d': "-",  // fullwidth hyphen-minus
+}
+
+const (
+	enDash = '\u2013'
+
+	// spaces are the invisible characters that can sneak in front of a token.
+	spaces = "\u00a0\u2007\u202f\ufeff"
+)
+
+// fixConfusable returns tok with any leading non-breaking spaces removed and
+// any leading dash lookalikes replaced by ASCII dashes. It returns false if
+// there was nothing to fix, or if the result would not look like an option.
+//
+// Word processors turn -- into an en dash as often as an em dash, so an en
+// dash on its own becomes -- when followed by a long name and - when followed
+// by a single character.
+func fixConfusable(tok string) (string, bool) {
+	rest := strings.TrimLeft(tok, spaces)
+	changed := rest != tok
+
+	var prefix strings.Builder
+	nrunes := 0
+	onlyEnDash := true
+
+	for rest != "" {
+		r, size := utf8.DecodeRuneInString(rest)
+		dash, ok := dashes[r]
+		if r == enDash {
+			dash, ok = "-", true
+		}
+
+		if !ok {
+			break
+		}
+
+		prefix.WriteString(dash)
+		changed = changed || r != '-'
+		onlyEnDash = onlyEnDash && r == enDash
+		nrunes++
+		rest = rest[size:]
+	}
+
+	if !changed || nrunes == 0 {
+		return tok, false
+	}
+
+	fixed := prefix.String()
+	if onlyEnDash && nrunes == 1 {
+		name, _, _ := strings.Cut(rest, "=")
+		if utf8.RuneCountInString(name) > 1 {
+			fixed = "--"
+		}
+	}
+
+	return fixed + rest, true
+}
@@ -89,6 +89,28 @@ func ExampleParser_Values() {
 	// command: ["echo" "Hello world"]
 }
 
+func ExampleParser_Peek() {
+	// --color takes a value only if one follows it.
+	parser := lexopt.NewFromArgs([]string{"--color", "never", "--color", "-v"})
+	for parser.Next() {
+		switch arg := parser.Current; arg {
+		case lexopt.Long("color"):
+			when := "always"
+			if next, ok := parser.Peek(); ok && !next.IsOption() {
+				val, _ := parser.Value()
+				when = val.String()
+			}
+			fmt.Println("color:", when)
+		default:
+			fmt.Println("other:", arg.DashedString())
+		}
+	}
+	// OUTPUT:
+	// color: never
+	// color: always
+	// other: -v
+}
+
 func ExampleRawArgs() {
 	parser := lexopt.NewFromArgs([]string{"-c", "file", "-o", "output", "stop", "-q"})
 	args, _ := parser.RawArgs()
@@ -0,0 +1,162 @@
+package lexopt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DuplicateKeyError is returned by [MapCollector.Add] when a key is given
+// more than once and the collector's policy is DuplicatesReject.
+type DuplicateKeyError struct {
+	Key string
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("duplicate key %q", e.Key)
+}
+
+// KeyValue splits the argument at its first equals sign, as for -D name=value
+// or --label key=value. The value may be empty, as in name=, but the key may
+// not. Nothing is unquoted; use a [MapCollector] for that.
+//
+// Since [Parser.Value] returns the rest of a cuddled short option as its
+// value, this also handles -Dname=value.
+func (a Arg) KeyValue() (string, string, error) {
+	key, val, ok := strings.Cut(a.s, "=")
+	if !ok || key == "" {
+		return "", "", &ParseError{Value: a.s, Want: "key=value"}
+	}
+	return key, val, nil
+}
+
+// DuplicatePolicy says what a [MapCollector] does when a key is given again.
+type DuplicatePolicy int
+
+const (
+	// DuplicatesReplace keeps the last value given, so that later options
+	// override earlier ones. This is the default.
+	DuplicatesReplace DuplicatePolicy = iota
+
+	// DuplicatesKeep keeps the first value given and ignores the rest.
+	DuplicatesKeep
+
+	// DuplicatesReject makes [MapCollector.Add] fail with a
+	// [DuplicateKeyError].
+	DuplicatesReject
+)
+
+// MapCollector gathers key=value pairs from repeated options, like
+// --label a=b --label c=d, into a map. The zero value collects strings, one
+// pair per value, into a new map:
+//
+//	var labels lexopt.MapCollector[string]
+//	...
+//	case lexopt.Long("label"):
+//		val, err := p.Value()
+//		...
+//		err = labels.Add(val)
+//
+// For other value types, set Convert, which can be a conversion method
+// expression like [Arg.Int]:
+//
+//	limits := lexopt.MapCollector[int]{Convert: lexopt.Arg.Int}
+type MapCollector[V any] struct {
+	// Map is where the pairs go. If it is nil, Add makes a new map.
+	Map map[string]V
+
+	// Sep separates a key from its value. If empty, it is "=".
+	Sep string
+
+	// PairSep, if not empty, separates several pairs in a single value, like
+	// the comma in --label a=b,c=d.
+	PairSep string
+
+	// Quotes enables shell-like quoting: a backslash escapes the next
+	// character, and single or double quotes protect the text between them,
+	// so that it can contain separators, as in --label 'list="a,b"'. The
+	// quotes and backslashes are removed.
+	Quotes bool
+
+	// Duplicates says what to do when a key is given again.
+	Duplicates DuplicatePolicy
+
+	// Convert converts each value. If nil, V must be string.
+	Convert func(Arg) (V, error)
+}
+
+// Add adds the pairs in a to the map. On error, the pairs before the bad one
+// have already been added.
+func (c *MapCollector[V]) Add(a Arg) error {
+	sep := c.Sep
+	if sep == "" {
+		sep = "="
+	}
+
+	pairs := []string{a.s}
+	if c.PairSep != "" {
+		var err error
+		if pairs, err = c.split(a.s, c.PairSep, -1, false); err != nil {
+			return err
+		}
+	}
+
+	if c.Map == nil {
+		c.Map = make(map[string]V)
+	}
+
+	for _, pair := range pairs {
+		kv, err := c.split(pair, sep, 2, true)
+		if err != nil {
+			return err
+		}
+
+		if len(kv) != 2 || kv[0] == "" {
+			return &ParseError{Value: pair, Want: "key" + sep + "value"}
+		}
+
+		key := kv[0]
+		val, err := c.convert(Value(kv[1]))
+		if err != nil {
+			return err
+		}
+
+		if _, seen := c.Map[key]; seen {
+			switch c.Duplicates {
+			case DuplicatesKeep:
+				continue
+			case DuplicatesReject:
+				return &DuplicateKeyError{Key: key}
+			}
+		}
+
+		c.Map[key] = val
+	}
+
+	return nil
+}
+
+// split splits s like strings.SplitN, honoring quotes if they're enabled.
+func (c *MapCollector[V]) split(s, sep string, n int, unquote bool) ([]string, error) {
+	if !c.Quotes {
+		return strings.SplitN(s, sep, n), nil
+	}
+
+	fields, err := splitQuoted(s, sep, n, unquote)
+	if err != nil {
+		return nil, &ParseError{Value: s, Want: "balanced quotes", Err: err}
+	}
+	return fields, nil
+}
+
+// convert converts a value with Convert, if it's set.
+func (c *MapCollector[V]) convert(a Arg) (V, error) {
+	if c.Convert != nil {
+		return c.Convert(a)
+	}
+
+	v, ok := any(a.s).(V)
+	if !ok {
+		panic("lexopt: MapCollector needs a Convert function for non-string values")
+	}
+	return v, nil
+}
@@ -0,0 +1,195 @@
+package lexopt
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestKeyValue(t *testing.T) {
+	good := map[string][2]string{
+		"name=value":  {"name", "value"},
+		"name=":       {"name", ""},
+		"a=b=c":       {"a", "b=c"},
+		"path=/x y/z": {"path", "/x y/z"},
+	}
+
+	for in, expect := range good {
+		k, v, err := Value(in).KeyValue()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", in, err)
+			continue
+		}
+
+		if k != expect[0] || v != expect[1] {
+			t.Errorf("%s: want %q, %q, got %q, %q", in, expect[0], expect[1], k, v)
+		}
+	}
+
+	for _, in := range []string{"", "name", "=value"} {
+		_, _, err := Value(in).KeyValue()
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: expected ParseError, got %v", in, err)
+		}
+	}
+}
+
+func TestKeyValueCuddled(t *testing.T) {
+	p := newTesterArgs(t, "-Dfoo=bar", "-D", "x=y", "--define=a=b")
+
+	var defs MapCollector[string]
+	for i := 0; i < 3; i++ {
+		p.nextOk()
+
+		val, err := p.Value()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if err := defs.Add(val); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	expect := map[string]string{"foo": "bar", "x": "y", "a": "b"}
+	if !reflect.DeepEqual(defs.Map, expect) {
+		t.Errorf("want %v, got %v", expect, defs.Map)
+	}
+}
+
+func TestMapCollector(t *testing.T) {
+	add := func(c interface{ Add(Arg) error }, vals ...string) error {
+		for _, val := range vals {
+			if err := c.Add(Value(val)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	t.Run("pairs", func(t *testing.T) {
+		c := MapCollector[string]{PairSep: ","}
+		if err := add(&c, "a=b,c=d", "e=", "a=z"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		expect := map[string]string{"a": "z", "c": "d", "e": ""}
+		if !reflect.DeepEqual(c.Map, expect) {
+			t.Errorf("want %v, got %v", expect, c.Map)
+		}
+	})
+
+	t.Run("quotes", func(t *testing.T) {
+		c := MapCollector[string]{PairSep: ",", Quotes: true}
+		err := add(&c, `list="a,b",x=1\,2`, `'k=v'=it's`, `path='C:\dir'`)
+		if err == nil {
+			t.Fatalf("unbalanced quote should be an error")
+		}
+
+		c = MapCollector[string]{PairSep: ",", Quotes: true}
+		if err := add(&c, `list="a,b",x=1\,2`, `'k=v'=its`, `path='C:\dir'`, `q="say \"hi\""`); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		expect := map[string]string{
+			"list": "a,b",
+			"x":    "1,2",
+			"k=v":  "its",
+			"path": `C:\dir`,
+			"q":    `say "hi"`,
+		}
+		if !reflect.DeepEqual(c.Map, expect) {
+			t.Errorf("want %v, got %v", expect, c.Map)
+		}
+	})
+
+	t.Run("no quotes", func(t *testing.T) {
+		c := MapCollector[string]{PairSep: ","}
+		if err := add(&c, `msg="a`); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if c.Map["msg"] != `"a` {
+			t.Errorf("quotes should be literal by default, got %q", c.Map["msg"])
+		}
+	})
+
+	t.Run("separators", func(t *testing.T) {
+		c := MapCollector[string]{Sep: ":", PairSep: ";"}
+		if err := add(&c, "a:b;c:d=e"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		expect := map[string]string{"a": "b", "c": "d=e"}
+		if !reflect.DeepEqual(c.Map, expect) {
+			t.Errorf("want %v, got %v", expect, c.Map)
+		}
+
+		err := add(&c, "a=b")
+		var perr *ParseError
+		if !errors.As(err, &perr) || perr.Want != "key:value" {
+			t.Errorf("expected ParseError naming the separator, got %v", err)
+		}
+	})
+
+	t.Run("duplicates", func(t *testing.T) {
+		keep := MapCollector[string]{Duplicates: DuplicatesKeep}
+		if err := add(&keep, "a=1", "a=2"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if keep.Map["a"] != "1" {
+			t.Errorf("DuplicatesKeep: want first value, got %q", keep.Map["a"])
+		}
+
+		reject := MapCollector[string]{Duplicates: DuplicatesReject, PairSep: ","}
+		err := add(&reject, "a=1,b=2,a=3")
+		var derr *DuplicateKeyError
+		if !errors.As(err, &derr) || derr.Key != "a" {
+			t.Fatalf("expected DuplicateKeyError for a, got %v", err)
+		}
+		if reject.Map["a"] != "1" || reject.Map["b"] != "2" {
+			t.Errorf("pairs before the error should be added, got %v", reject.Map)
+		}
+	})
+
+	t.Run("convert", func(t *testing.T) {
+		c := MapCollector[int]{PairSep: ",", Convert: Arg.Int}
+		if err := add(&c, "cpu=2,mem=512"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		expect := map[string]int{"cpu": 2, "mem": 512}
+		if !reflect.DeepEqual(c.Map, expect) {
+			t.Errorf("want %v, got %v", expect, c.Map)
+		}
+
+		err := add(&c, "cpu=lots")
+		if !errors.Is(err, strconv.ErrSyntax) {
+			t.Errorf("expected conversion error, got %v", err)
+		}
+	})
+
+	t.Run("existing map", func(t *testing.T) {
+		m := map[string]string{"a": "1"}
+		c := MapCollector[string]{Map: m}
+		if err := add(&c, "b=2"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if m["b"] != "2" {
+			t.Errorf("should add to the given map, got %v", m)
+		}
+	})
+
+	t.Run("no convert", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected panic without Convert")
+			}
+		}()
+
+		var c MapCollector[int]
+		_ = c.Add(Value("a=1"))
+	})
+}
@@ -7,16 +7,22 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"strings"
+	"unicode"
 )
 
+// The parser's errors are wrapped in an [ArgError] that says where on the
+// command line they happened, so check for these with errors.Is rather than
+// ==.
 var (
-	// ErrUnexpectedValue is returned when an argument has a value that was not
-	// consumed by a call to parser.Value().
+	// ErrUnexpectedValue is wrapped by the error returned when an argument has
+	// a value that was not consumed by a call to parser.Value().
 	ErrUnexpectedValue = fmt.Errorf("unexpected value")
 
-	// ErrNoValue is returned when a value is expected, but not available.
+	// ErrNoValue is wrapped by the error returned when a value is expected,
+	// but not available.
 	ErrNoValue = fmt.Errorf("no value found")
 
 	// errNoToken is internal-only, raised when parser.argv is exhausted.
@@ -29,16 +35,103 @@ type Parser struct {
 	// Current argument.
 	Current Arg
 
+	// PlusOptions enables options introduced with a plus sign, like set +e or
+	// xterm +sb. When it is set, +abc yields [Plus] options and ++name yields
+	// a [PlusLong] option, exactly as -abc and --name do with dashes. A lone
+	// + or ++ is a positional argument.
+	PlusOptions bool
+
+	// NumberOptions enables numeric options, like head -20 or nice -5. When it
+	// is set, a run of digits at the start of a short option cluster yields a
+	// single [Number] option, so -20 is Number(20) rather than -2 -0. Digits
+	// are only special at the start of a cluster: -20v yields Number(20) then
+	// Short('v'), but -v20 yields Short('v'), after which a call to
+	// [Parser.Value] returns "20" (and a call to [Parser.Next] returns the
+	// short options 2 and 0, as usual).
+	NumberOptions bool
+
+	// NormalizeLong, if set, is applied to the name of every long option
+	// before it is stored in Current, so that differently-spelled options
+	// can be matched with a single Long case. [FoldCase] and [FoldKebab] are
+	// useful normalizers. Use [Parser.DashedCurrent] to report the option as
+	// the user typed it.
+	NormalizeLong func(string) string
+
+	// Confusables controls what happens when a token starts with a Unicode
+	// lookalike of a dash, such as an em dash. By default such tokens are
+	// ordinary arguments; see [ConfusableMode] for the alternatives.
+	Confusables ConfusableMode
+
+	// CollectErrors makes the parser keep going after an error, rather than
+	// stopping at the first one. When it is set and Next finds an unconsumed
+	// value (as in --flag=value for a flag that takes none), it records the
+	// error, skips the value, and carries on; likewise for tokens rejected by
+	// [ConfusablesReject]. [Parser.Err] then returns all the errors found,
+	// joined with [errors.Join]. Use [Parser.AddErr] to add your own.
+	CollectErrors bool
+
+	// Secrets marks options whose values are secrets, like --password or
+	// --token. Their values are redacted in the parser's debugging output
+	// and in [Parser.Annotate], and [SecretSource.Value] applies the policy
+	// given for each. Keys are matched against Current, so long options are
+	// given after NormalizeLong.
+	Secrets map[Arg]SecretPolicy
+
+	// Trace, if set, logs every step the parser takes at debug level: the
+	// tokens consumed, the state before and after, the Arg produced and any
+	// error. It's meant for working out why an option isn't being seen the
+	// way you expect; see also [Parser.Explain]. Values of options in Secrets
+	// are redacted. To trace to stderr:
+	//
+	//	p.Trace = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	Trace *slog.Logger
+
 	// internal state
 
-	binName  string   // $0, possibly empty
-	argv     []string // original args, not including binName
-	idx      int      // index into argv
-	state    state    // current parser state
-	pending  string   // when state=pendingValue, the value that's pending
-	short    []rune   // when state=short, the code points for the current arg
-	shortpos int      // index into short
-	err      error    // can be set when Next() returns false
+	binName string // $0, possibly empty
+	quiet   bool   // whether to skip tracing, as while peeking
+	cursor         // everything else, which changes as parsing goes on
+}
+
+// The cursor type holds the parts of the parser's internal state that change
+// as arguments are consumed. Keeping them together means that a Checkpoint is
+// just a copy of the cursor.
+type cursor struct {
+	argv      []string // original args, not including binName
+	idx       int      // index into argv
+	state     state    // current parser state
+	pending   string   // when state=pendingValue, the value that's pending
+	short     []rune   // when state=short, the code points for the current arg
+	shortpos  int      // index into short
+	shortkind argType  // when state=short, the kind of arg to yield (short or plus)
+	typed     string   // when Current is a long option, its name as typed
+	err       error    // can be set when Next() returns false
+	errs      []error  // errors collected with CollectErrors, or AddErr
+
+	tok     string   // the token being parsed, after fixing any confusables
+	tokidx  int      // index of tok in argv
+	fixlen  int      // length of the dashes fixConfusable put at the front of tok
+	origlen int      // length of the characters they replaced in argv[tokidx]
+	curpos  Position // position of Current
+	pos     Position // position of the last Arg returned
+
+	valpos []Position // positions of the values from the last Values call
+
+	held    []heldCluster // clusters set aside by Insert, the next to resume last
+	aliased []aliasedArg  // arguments inserted by Aliases.Expand
+}
+
+// A heldCluster is the rest of a cluster of short options that Insert set
+// aside, along with the token it came from, until the parser gets to argv[at].
+type heldCluster struct {
+	at        int
+	short     []rune
+	shortpos  int
+	shortkind argType
+	tok       string
+	tokidx    int
+	fixlen    int
+	origlen   int
 }
 
 // The state type is used for storing the internal state of the parser.
@@ -56,7 +149,7 @@ const (
 func New(fullArgv []string) *Parser {
 	return &Parser{
 		binName: fullArgv[0],
-		argv:    fullArgv[1:],
+		cursor:  cursor{argv: fullArgv[1:]},
 	}
 }
 
@@ -70,7 +163,7 @@ func NewFromEnv() *Parser {
 // contain the binary name.
 func NewFromArgs(argv []string) *Parser {
 	return &Parser{
-		argv: argv,
+		cursor: cursor{argv: argv},
 	}
 }
 
@@ -81,37 +174,102 @@ func (p *Parser) BinName() string {
 	return p.binName
 }
 
+// FoldCase is a normalizer for [Parser.NormalizeLong] that makes long options
+// case-insensitive, so that --Verbose and --VERBOSE both match
+// Long("verbose").
+func FoldCase(name string) string {
+	return strings.ToLower(name)
+}
+
+// FoldKebab is a normalizer for [Parser.NormalizeLong] that converts long
+// option names to lower-case kebab-case. Underscores become dashes and a
+// dash is inserted at every camel-case word boundary, so --DryRun, --dryRun,
+// --dry_run and --DRY-RUN all match Long("dry-run").
+func FoldKebab(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if r == '_' {
+			b.WriteByte('-')
+			continue
+		}
+
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				b.WriteByte('-')
+			}
+		}
+
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
+
 // Next advances Parser's internal iterator, possibly setting
 // Parser.Current. It returns true if it successfully sets Parser.Current,
 // false otherwise. After consuming all arguments with Next, you should check
 // the result of [Parser.Err]. If it is non-nil, it contains the parse error
 // that caused iteration to fail.
 func (p *Parser) Next() bool {
+	step := p.beginTrace("Next")
+	ok := p.next()
+
+	var arg Arg
+	if ok {
+		arg = p.Current
+	}
+	p.endTrace(step, arg, nil)
+
+	return ok
+}
+
+// next does the work for Next, which wraps it for tracing.
+func (p *Parser) next() bool {
+	p.resume()
+
 	switch p.state {
 	case pendingValue:
 		// We have an --long=value with an unconsumed value; this is an error.
-		p.err = ErrUnexpectedValue
-		return false
+		if !p.recordErr(p.unexpectedValue()) {
+			return false
+		}
+
+		p.state = empty
+		p.pending = ""
+		return p.next()
 
 	case short:
 		// We have an -s=value with an unconsumed value; this is an error.
 		if p.short[p.shortpos] == '=' && p.shortpos >= 1 {
-			p.err = ErrUnexpectedValue
-			return false
+			if !p.recordErr(p.unexpectedValue()) {
+				return false
+			}
+
+			p.resetShort("", argShort)
+			return p.next()
 		}
 
 		// Take the next short option out of an -abc set.
-		p.Current = p.takeShort()
-		return true
+		return p.takeShort()
 
 	case finished:
+		if p.heldDue() {
+			// After --, the rest of a cluster is a positional argument.
+			rest, start := p.heldRest()
+			return p.yield(Value(rest), start, len(p.tok))
+		}
+
 		nextTok, err := p.nextTok()
 		if err != nil {
 			return false
 		}
 
-		p.Current = Value(nextTok)
-		return true
+		p.beginToken(nextTok)
+		return p.yield(Value(nextTok), 0, len(nextTok))
 	}
 
 	if p.state != empty {
@@ -123,10 +281,31 @@ func (p *Parser) Next() bool {
 		return false
 	}
 
+	p.beginToken(nextTok)
+	if fixed, ok := p.fixConfusable(nextTok); ok {
+		if p.Confusables == ConfusablesReject {
+			err := &ArgError{
+				Pos: wholeToken(p.argv, p.tokidx),
+				Err: &ConfusableError{Token: nextTok, Suggestion: fixed},
+			}
+
+			if !p.recordErr(err) {
+				return false
+			}
+
+			return p.next()
+		}
+
+		p.tok = fixed
+		p.fixlen = len(fixed) - len(strings.TrimLeft(fixed, "-"))
+		p.origlen = p.fixlen + len(nextTok) - len(fixed)
+		nextTok = fixed
+	}
+
 	switch {
 	case nextTok == "--":
 		p.state = finished
-		return p.Next()
+		return p.next()
 
 	case strings.HasPrefix(nextTok, "--"):
 		p.state = empty
@@ -137,22 +316,100 @@ func (p *Parser) Next() bool {
 			p.pending = after
 			p.state = pendingValue
 		}
-		p.Current = Long(before)
-		return true
+		return p.yield(p.long(argLong, before), 0, 2+len(before))
 
 	case strings.HasPrefix(nextTok, "-"):
 		if nextTok == "-" {
-			p.Current = Value(nextTok)
-			return true
+			return p.yield(Value(nextTok), 0, len(nextTok))
 		}
 
-		p.resetShort(nextTok[1:])
-		p.Current = p.takeShort()
-		return true
+		p.resetShort(nextTok[1:], argShort)
+		if n := countDigits(nextTok[1:]); p.NumberOptions && n > 0 {
+			digits := strings.TrimLeft(nextTok[1:n+1], "0")
+			if digits == "" {
+				digits = "0"
+			}
+			p.skipShort(n)
+			return p.yield(Arg{argNumber, digits}, 0, 1+n)
+		}
+
+		return p.takeShort()
+
+	case p.PlusOptions && isPlusOption(nextTok):
+		if strings.HasPrefix(nextTok, "++") {
+			before, after, hasEqual := strings.Cut(nextTok[2:], "=")
+			if hasEqual {
+				p.pending = after
+				p.state = pendingValue
+			}
+			return p.yield(p.long(argPlusLong, before), 0, 2+len(before))
+		}
+
+		p.resetShort(nextTok[1:], argPlus)
+		return p.takeShort()
 
 	default:
-		p.Current = Value(nextTok)
-		return true
+		return p.yield(Value(nextTok), 0, len(nextTok))
+	}
+}
+
+// Peek returns the Arg that the next call to [Parser.Next] would set Current
+// to, without consuming it: the parser is left exactly as it was. It returns
+// false if Next would return false, either because the arguments have run out
+// or because of an error. Peek splits clusters of short options and respects
+// -- just as Next does, so it can be used to decide whether to take an
+// option's value based on what comes next:
+//
+//	if next, ok := p.Peek(); ok && !next.IsOption() { ... }
+func (p *Parser) Peek() (Arg, bool) {
+	cp := p.Checkpoint()
+	defer p.Restore(cp)
+
+	// Looking ahead isn't a decision worth tracing.
+	defer func(quiet bool) { p.quiet = quiet }(p.quiet)
+	p.quiet = true
+
+	if !p.Next() {
+		return Arg{}, false
+	}
+
+	return p.Current, true
+}
+
+// Position returns the position on the command line of the Arg most recently
+// returned by the parser, whether from [Parser.Next], [Parser.Value],
+// [Parser.OptionalValue] or [Parser.Values] (in which case it is the position
+// of the last value; see [Parser.ValuesPositions] for the others).
+func (p *Parser) Position() Position {
+	return p.pos
+}
+
+// ValuesPositions returns the positions on the command line of the values
+// returned by the most recent call to [Parser.Values], [Parser.ValuesN],
+// [Parser.ValuesUntil] or [Parser.ValuesWhile], in the same order, including
+// when the call returned an error along with some values.
+func (p *Parser) ValuesPositions() []Position {
+	return p.valpos
+}
+
+// CurrentPosition returns the position on the command line of
+// [Parser.Current]. Unlike [Parser.Position], it is not changed by fetching
+// an option's value.
+func (p *Parser) CurrentPosition() Position {
+	return p.curpos
+}
+
+// DashedCurrent is like p.Current.DashedString(), but reports a long option
+// as the user typed it, before [Parser.NormalizeLong] was applied. It's meant
+// for error messages.
+func (p *Parser) DashedCurrent() string {
+	switch p.Current.kind {
+	case argLong:
+		return "--" + p.typed
+	case argPlusLong:
+		return "++" + p.typed
+	default:
+		return p.Current.DashedString()
 	}
 }
 
@@ -176,13 +433,43 @@ func (p *Parser) OptionalValue() (Arg, bool) {
 	return ret, true
 }
 
+// OptionalChoice is for options with an optional value from a fixed set of
+// choices, like --color[=WHEN]. If a value is attached to the option (see
+// [Parser.OptionalValue]), it must be one of choices, or OptionalChoice
+// returns an error wrapping a [ChoiceError]. If no value is attached,
+// OptionalChoice returns def, so that --color means the same as
+// --color=always for:
+//
+//	when, err := p.OptionalChoice([]string{"auto", "always", "never"}, "always")
+func (p *Parser) OptionalChoice(choices []string, def string) (string, error) {
+	val, ok := p.OptionalValue()
+	if !ok {
+		return def, nil
+	}
+
+	choice, err := val.OneOf(choices...)
+	return choice, p.WrapErr(err)
+}
+
 // value is the internal implementation for Value and OptionalValue. The
 // middle boolean return is whether or not there was an equals sign (which
 // matters for Values).
 func (p *Parser) value() (Arg, bool, error) {
+	step := p.beginTrace("Value")
+	val, hasEqual, err := p.takeValue()
+	p.endTrace(step, val, err)
+
+	return val, hasEqual, err
+}
+
+// takeValue does the work for value, which wraps it for tracing.
+func (p *Parser) takeValue() (Arg, bool, error) {
+	p.resume()
+
 	switch p.state {
 	case pendingValue:
 		val := Value(p.pending)
+		p.pos = p.span(len(p.tok)-len(p.pending), len(p.tok))
 		p.state = empty
 		p.pending = ""
 		return val, true, nil
@@ -190,9 +477,10 @@ func (p *Parser) value() (Arg, bool, error) {
 	case empty:
 		val, err := p.nextTok()
 		if err != nil {
-			return Arg{}, false, ErrNoValue
+			return Arg{}, false, p.currentErr(ErrNoValue)
 		}
 
+		p.pos = wholeToken(p.argv, p.idx-1)
 		return Value(val), false, nil
 
 	case short:
@@ -200,11 +488,16 @@ func (p *Parser) value() (Arg, bool, error) {
 		raw := string(p.short[p.shortpos:])
 		hasEqual := strings.HasPrefix(raw, "=")
 		val := Value(strings.TrimPrefix(raw, "="))
-		p.resetShort("")
+		start := p.shortByte(p.shortpos)
+		if hasEqual {
+			start++
+		}
+		p.pos = p.span(start, len(p.tok))
+		p.resetShort("", argShort)
 		return val, hasEqual, nil
 
 	case finished:
-		return Arg{}, false, ErrNoValue
+		return Arg{}, false, p.currentErr(ErrNoValue)
 
 	default:
 		panic("unreachable")
@@ -223,10 +516,12 @@ func (p *Parser) value() (Arg, bool, error) {
 // --opt=b c will only yield "b" while -a b c, -ab c and --opt b c will yield
 // "b", "c".
 //
-// If not at least one value is found then it returns [ErrNoValue].
+// If not at least one value is found then it returns an error wrapping
+// [ErrNoValue].
 func (p *Parser) Values() ([]Arg, error) {
+	p.valpos = nil
 	if !p.hasPending() && !p.nextIsNormal() {
-		return nil, ErrNoValue
+		return nil, p.currentErr(ErrNoValue)
 	}
 
 	var vals []Arg
@@ -234,20 +529,300 @@ func (p *Parser) Values() ([]Arg, error) {
 	// Take one.
 	val, hadEqual, _ := p.value()
 	vals = append(vals, val)
+	p.valpos = append(p.valpos, p.pos)
 
 	// Take more, if we can.
 	for !hadEqual && p.nextIsNormal() {
+		step := p.beginTrace("Values")
 		val, _ := p.nextTok()
 		vals = append(vals, Value(val))
+		p.pos = wholeToken(p.argv, p.idx-1)
+		p.valpos = append(p.valpos, p.pos)
+		p.endTrace(step, Value(val), nil)
+	}
+
+	return vals, nil
+}
+
+// ValuesN gathers exactly n values for an option, as for a --point flag
+// that's invoked as app --point X Y Z. Unlike [Parser.Values], it takes
+// values even if they look like options, as [Parser.Value] does.
+//
+// An equals sign limits this to a single value, as with Values: if n is
+// greater than 1, --point=X is an error. If fewer than n values are
+// available, ValuesN returns the values it found and an error wrapping a
+// [ValuesError].
+//
+// ValuesN(0) takes nothing, not even a value attached to the option, and
+// returns an empty slice; a negative n is treated as 0.
+func (p *Parser) ValuesN(n int) ([]Arg, error) {
+	vals := make([]Arg, 0, max(n, 0))
+	p.valpos = nil
+	for len(vals) < n {
+		val, hadEqual, err := p.value()
+		if err != nil {
+			break
+		}
+
+		vals = append(vals, val)
+		p.valpos = append(p.valpos, p.pos)
+		if hadEqual {
+			break
+		}
+	}
+
+	if len(vals) < n {
+		return vals, p.currentErr(&ValuesError{Found: len(vals), Want: n})
+	}
+
+	return vals, nil
+}
+
+// ValuesUntil gathers values for an option up to a terminating argument,
+// like find's -exec, which is invoked as find -exec cmd {} ';'. The
+// terminator is consumed, but not returned. Like [Parser.ValuesN], it takes
+// values even if they look like options.
+//
+// An equals sign limits this to a single value, as with [Parser.Values], and
+// no terminator is needed: --exec=cmd yields just "cmd". Otherwise, if at
+// least one value is not found, or the command line ends before the
+// terminator does, ValuesUntil returns the values it found and an error
+// wrapping a [ValuesError].
+func (p *Parser) ValuesUntil(terminator string) ([]Arg, error) {
+	var vals []Arg
+	p.valpos = nil
+	if p.hasPending() {
+		val, hadEqual, _ := p.value()
+		vals = append(vals, val)
+		p.valpos = append(p.valpos, p.pos)
+		if hadEqual {
+			return vals, nil
+		}
+	}
+
+	for {
+		last := p.pos
+		val, _, err := p.value()
+		if err != nil {
+			return vals, p.currentErr(&ValuesError{Found: len(vals), Want: 1, Terminator: terminator})
+		}
+
+		if val.s == terminator {
+			p.pos = last
+			break
+		}
+
+		vals = append(vals, val)
+		p.valpos = append(p.valpos, p.pos)
+	}
+
+	if len(vals) == 0 {
+		return vals, p.currentErr(&ValuesError{Found: 0, Want: 1})
+	}
+
+	return vals, nil
+}
+
+// ValuesWhile gathers values for an option for as long as predicate returns
+// true. A value attached to the option, as in --opt=value or -ovalue, is
+// always taken without consulting the predicate, and, as with
+// [Parser.Values], an equals sign limits this to that single value. Nothing
+// is taken after --, but -- itself can be taken if predicate allows it, and
+// is then the last value.
+//
+// If not at least one value is found, it returns an error wrapping a
+// [ValuesError].
+func (p *Parser) ValuesWhile(predicate func(Arg) bool) ([]Arg, error) {
+	var vals []Arg
+	p.valpos = nil
+	if p.hasPending() {
+		val, hadEqual, _ := p.value()
+		vals = append(vals, val)
+		p.valpos = append(p.valpos, p.pos)
+		if hadEqual {
+			return vals, nil
+		}
+	}
+
+	for p.state == empty && !p.heldDue() && p.idx < len(p.argv) && predicate(Value(p.argv[p.idx])) {
+		val, _, _ := p.value()
+		vals = append(vals, val)
+		p.valpos = append(p.valpos, p.pos)
+
+		if val.s == "--" {
+			break
+		}
+	}
+
+	if len(vals) == 0 {
+		return vals, p.currentErr(&ValuesError{Found: 0, Want: 1})
 	}
 
 	return vals, nil
 }
 
+// ValuesError is returned by [Parser.ValuesN], [Parser.ValuesUntil] and
+// [Parser.ValuesWhile] when an option doesn't get the values it needs. It
+// wraps [ErrNoValue].
+type ValuesError struct {
+	Found      int    // the number of values found
+	Want       int    // the number of values wanted (at least)
+	Terminator string // for ValuesUntil, the terminator that was not found
+}
+
+func (e *ValuesError) Error() string {
+	if e.Terminator != "" {
+		return fmt.Sprintf("found %d values, but no terminating %q", e.Found, e.Terminator)
+	}
+
+	return fmt.Sprintf("wanted %d values, found %d", e.Want, e.Found)
+}
+
+func (e *ValuesError) Unwrap() error {
+	return ErrNoValue
+}
+
+// Insert splices args into the command line at the parser's current
+// position, so that the next call to [Parser.Next] sees them. This is useful
+// for expanding aliases and presets; see also [Aliases].
+//
+// If the parser is midway through a cluster of short options, as after -a
+// in -abc, the rest of the cluster is set aside, and the parser picks it up
+// where it left off once it has got through the inserted arguments, so that
+// -bc still yields -b and -c. (If the inserted arguments include --, the rest
+// of the cluster is a positional argument, "bc".) After --, the inserted
+// arguments are positional, like everything else.
+//
+// Insert returns an error wrapping [ErrUnexpectedValue] if the last option
+// has a value attached, as in --option=value or -o=value, because the value
+// would be orphaned. Consume it first with [Parser.Value].
+func (p *Parser) Insert(args ...string) error {
+	switch {
+	case p.state == pendingValue:
+		return p.unexpectedValue()
+
+	case p.state == short && p.short[p.shortpos] == '=' && p.shortpos >= 1:
+		return p.unexpectedValue()
+	}
+
+	// Clusters set aside already are resumed after the new arguments, and
+	// arguments inserted by aliases move along with the rest. As with argv,
+	// always copy, so that no Checkpoint is affected.
+	held := make([]heldCluster, len(p.held), len(p.held)+1)
+	copy(held, p.held)
+	for i := range held {
+		if held[i].at >= p.idx {
+			held[i].at += len(args)
+		}
+	}
+
+	aliased := make([]aliasedArg, len(p.aliased))
+	copy(aliased, p.aliased)
+	for i := range aliased {
+		if aliased[i].idx >= p.idx {
+			aliased[i].idx += len(args)
+		}
+	}
+	p.aliased = aliased
+
+	if p.state == short {
+		held = append(held, heldCluster{
+			at:        p.idx + len(args),
+			short:     p.short,
+			shortpos:  p.shortpos,
+			shortkind: p.shortkind,
+			tok:       p.tok,
+			tokidx:    p.tokidx,
+			fixlen:    p.fixlen,
+			origlen:   p.origlen,
+		})
+		p.resetShort("", argShort)
+	}
+	p.held = held
+
+	// Always copy, so that neither the caller's slice nor any Checkpoint is
+	// affected.
+	argv := make([]string, 0, len(p.argv)+len(args))
+	argv = append(argv, p.argv[:p.idx]...)
+	argv = append(argv, args...)
+	p.argv = append(argv, p.argv[p.idx:]...)
+	return nil
+}
+
 // Err returns the last error seen by [Parser.Next]. If argument processing
-// ended normally, Err returns nil.
+// ended normally, Err returns nil. If [Parser.CollectErrors] is set, or
+// [Parser.AddErr] has been called, Err returns all of the errors seen,
+// joined with [errors.Join]; if there was only one, it is returned as is.
 func (p *Parser) Err() error {
-	return p.err
+	errs := p.errs
+	if p.err != nil {
+		errs = append(errs[:len(errs):len(errs)], p.err)
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return errors.Join(errs...)
+	}
+}
+
+// AddErr adds err to the errors returned by [Parser.Err], so that your own
+// validation errors can be reported alongside the parser's. It's most useful
+// with [Parser.CollectErrors]. Nil errors are ignored.
+func (p *Parser) AddErr(err error) {
+	if err != nil {
+		p.errs = append(p.errs, err)
+	}
+}
+
+// recordErr records err, which Next has just run into. It returns true if the
+// parser should skip over the problem and carry on, and false if Next should
+// stop.
+func (p *Parser) recordErr(err error) bool {
+	if !p.CollectErrors {
+		p.err = err
+		return false
+	}
+
+	p.errs = append(p.errs, err)
+	return true
+}
+
+// WrapErr wraps err in an [ArgError] that points at the Arg most recently
+// returned by the parser (see [Parser.Position]). It's meant for errors from
+// conversions, like a value that doesn't parse as an int, so that they can
+// be shown with [Parser.Annotate]. If err is nil or already an ArgError,
+// WrapErr returns it unchanged.
+func (p *Parser) WrapErr(err error) error {
+	var argErr *ArgError
+	if err == nil || errors.As(err, &argErr) {
+		return err
+	}
+
+	return &ArgError{Pos: p.pos, Err: err}
+}
+
+// unexpectedValue returns an ErrUnexpectedValue pointing at the value that is
+// pending, in state pendingValue, or the rest of the cluster, in state short.
+func (p *Parser) unexpectedValue() error {
+	start := p.shortByte(p.shortpos)
+	if p.state == pendingValue {
+		start = len(p.tok) - len(p.pending) - 1
+	}
+
+	return &ArgError{Pos: p.span(start, len(p.tok)), Err: ErrUnexpectedValue}
+}
+
+// currentErr wraps err in an ArgError pointing at Current, if there is one.
+func (p *Parser) currentErr(err error) error {
+	if p.Current == (Arg{}) {
+		return err
+	}
+
+	return &ArgError{Pos: p.curpos, Err: err}
 }
 
 // nextTok advances the internal iterator, returning the next token.
@@ -261,11 +836,80 @@ func (p *Parser) nextTok() (string, error) {
 	return next, nil
 }
 
-// takeShort returns the next short option out of p.short, updating the
-// internal state as required.
-func (p *Parser) takeShort() Arg {
-	ret := Short(p.short[p.shortpos])
-	p.shortpos++
+// long returns a long option of the given kind, applying NormalizeLong and
+// remembering the name as typed.
+func (p *Parser) long(kind argType, name string) Arg {
+	p.typed = name
+	if p.NormalizeLong != nil {
+		name = p.NormalizeLong(name)
+	}
+	return Arg{kind, name}
+}
+
+// takeShort sets Current to the next short option out of p.short, updating
+// the internal state as required. It always returns true.
+func (p *Parser) takeShort() bool {
+	arg := Arg{p.shortkind, string(p.short[p.shortpos])}
+
+	// The first short option in a cluster takes its dash with it.
+	start, end := p.shortByte(p.shortpos), p.shortByte(p.shortpos+1)
+	if p.shortpos == 0 {
+		start = 0
+	}
+
+	p.skipShort(1)
+	return p.yield(arg, start, end)
+}
+
+// shortByte returns the byte offset in p.tok of p.short[i].
+func (p *Parser) shortByte(i int) int {
+	return 1 + len(string(p.short[:i]))
+}
+
+// beginToken records tok, which has just been taken from argv, as the token
+// being parsed.
+func (p *Parser) beginToken(tok string) {
+	p.tok = tok
+	p.tokidx = p.idx - 1
+	p.fixlen = 0
+	p.origlen = 0
+}
+
+// yield sets Current to arg, which spans p.tok[start:end]. It always returns
+// true, for the convenience of Next.
+func (p *Parser) yield(arg Arg, start, end int) bool {
+	p.Current = arg
+	p.curpos = p.span(start, end)
+	p.pos = p.curpos
+	return true
+}
+
+// span returns the Position of p.tok[start:end]. If fixConfusable changed the
+// token, the offsets are mapped back onto the original token.
+func (p *Parser) span(start, end int) Position {
+	unfix := func(off int) int {
+		switch {
+		case off == 0:
+			return 0
+		case off < p.fixlen:
+			return p.origlen
+		default:
+			return off - p.fixlen + p.origlen
+		}
+	}
+
+	return Position{
+		Index: p.tokidx,
+		Start: unfix(start),
+		End:   unfix(end),
+		Token: p.argv[p.tokidx],
+	}
+}
+
+// skipShort advances shortpos by n code points, updating the internal state
+// as required.
+func (p *Parser) skipShort(n int) {
+	p.shortpos += n
 
 	if p.shortpos >= len(p.short) {
 		p.state = empty
@@ -274,15 +918,57 @@ func (p *Parser) takeShort() Arg {
 	} else {
 		p.state = short
 	}
+}
+
+// rest returns the rest of the cluster as it is written.
+func (h heldCluster) rest() string {
+	return h.tok[1+len(string(h.short[:h.shortpos])):]
+}
+
+// heldDue returns true if the parser has got through the arguments that
+// Insert put before the cluster it set aside most recently.
+func (p *Parser) heldDue() bool {
+	return len(p.held) > 0 && p.held[len(p.held)-1].at == p.idx
+}
+
+// resume returns to the cluster that Insert set aside most recently, if it is
+// due and the parser is between arguments.
+func (p *Parser) resume() {
+	if p.state != empty || !p.heldDue() {
+		return
+	}
+
+	h := p.popHeld()
+	p.short = h.short
+	p.shortpos = h.shortpos
+	p.shortkind = h.shortkind
+	p.state = short
+}
 
-	return ret
+// heldRest takes the cluster that Insert set aside most recently as it is
+// written, for when it is no longer options. It returns the text and where it
+// starts in p.tok.
+func (p *Parser) heldRest() (string, int) {
+	h := p.popHeld()
+	start := len(p.tok) - len(h.rest())
+	return p.tok[start:], start
+}
+
+// popHeld removes the cluster that Insert set aside most recently, and makes
+// its token the one being parsed.
+func (p *Parser) popHeld() heldCluster {
+	h := p.held[len(p.held)-1]
+	p.held = p.held[:len(p.held)-1]
+	p.tok, p.tokidx, p.fixlen, p.origlen = h.tok, h.tokidx, h.fixlen, h.origlen
+	return h
 }
 
 // resetShort sets p.short to a rune slice of value, updating the internal
-// state as required.
-func (p *Parser) resetShort(value string) {
+// state as required. The kind is the kind of Arg that takeShort will yield.
+func (p *Parser) resetShort(value string, kind argType) {
 	p.short = []rune(value)
 	p.shortpos = 0
+	p.shortkind = kind
 
 	if value == "" {
 		p.state = empty
@@ -308,12 +994,20 @@ func (p *Parser) hasPending() bool {
 
 // nextIsNormal returns true if the next token is a non-option.
 func (p *Parser) nextIsNormal() bool {
+	if p.heldDue() {
+		// The rest of a cluster of options
+		return false
+	}
+
 	if p.idx >= len(p.argv) {
 		// out of options
 		return false
 	}
 
 	next := p.argv[p.idx]
+	if fixed, ok := p.fixConfusable(next); ok {
+		next = fixed
+	}
 
 	switch {
 	case p.state == finished:
@@ -322,40 +1016,98 @@ func (p *Parser) nextIsNormal() bool {
 	case next == "-":
 		return true
 
+	case p.PlusOptions && isPlusOption(next):
+		return false
+
 	default:
 		return !strings.HasPrefix(next, "-")
 	}
 }
 
+// fixConfusable is like the package-level fixConfusable, but only does
+// anything if the parser is configured to care about confusables.
+func (p *Parser) fixConfusable(tok string) (string, bool) {
+	if p.Confusables == ConfusablesIgnore {
+		return tok, false
+	}
+	return fixConfusable(tok)
+}
+
+// countDigits returns the number of leading ASCII digits in s.
+func countDigits(s string) int {
+	n := 0
+	for n < len(s) && '0' <= s[n] && s[n] <= '9' {
+		n++
+	}
+	return n
+}
+
+// isPlusOption returns true if tok looks like a plus option: +x or ++name,
+// but not a lone + or ++.
+func isPlusOption(tok string) bool {
+	return strings.HasPrefix(tok, "+") && tok != "+" && tok != "++"
+}
+
 // dumpState writes the parser state to out, which defaults to os.Stdout. It's
-// useful for debugging.
+// useful for debugging. The values of options in Secrets are redacted.
 func (p *Parser) dumpState(out ...io.Writer) {
 	var w io.Writer = os.Stdout
 	if len(out) > 0 {
 		w = out[0]
 	}
 
+	argv := p.redactArgv()
+	hidden := func(pos Position) bool { return p.hidden(argv, pos) }
+
+	_, secret := p.Secrets[p.Current]
+
 	fmt.Fprintln(w, "--- parser state ---")
-	fmt.Fprintln(w, "Current:    ", p.Current)
-	fmt.Fprintln(w, "argv:       ", p.argv)
+	if !p.Current.IsOption() && hidden(p.curpos) {
+		fmt.Fprintln(w, "Current:    ", redacted)
+	} else {
+		fmt.Fprintln(w, "Current:    ", p.Current)
+	}
+	fmt.Fprintln(w, "argv:       ", argv)
 	fmt.Fprintln(w, "idx:        ", p.idx)
 	fmt.Fprintln(w, "state:      ", p.state)
-	fmt.Fprintln(w, "pending arg:", p.pending)
-	fmt.Fprintln(w, "short:      ", p.short)
+	if secret && p.pending != "" {
+		fmt.Fprintln(w, "pending arg:", redacted)
+	} else {
+		fmt.Fprintln(w, "pending arg:", p.pending)
+	}
+	if secret && p.state == short {
+		fmt.Fprintln(w, "short:      ", redacted)
+	} else {
+		fmt.Fprintln(w, "short:      ", p.short)
+	}
 	fmt.Fprintln(w, "shortpos:   ", p.shortpos)
-	fmt.Fprintln(w, "err:        ", p.err)
+	if err := p.Err(); err != nil {
+		fmt.Fprintln(w, "err:        ", p.redactErr(argv, err))
+	} else {
+		fmt.Fprintln(w, "err:        ", err)
+	}
+	if hidden(p.pos) {
+		fmt.Fprintf(w, "position:    %s (argument %d)\n", redacted, p.pos.Index+1)
+	} else {
+		fmt.Fprintln(w, "position:   ", p.pos)
+	}
 	fmt.Fprintln(w, "---")
 }
 
 // RawArgs takes raw arguments from the middle of the original command line.
 // The return value is a [RawArgs] struct, which can be used as an iterator.
 //
-// RawArgs returns [ErrUnexpectedValue] if the last option had a left-over
-// argument, as in --option=value, -ovalue, or if it was midway through an
-// option chain, as in -abc.
+// RawArgs returns an error wrapping [ErrUnexpectedValue] if the last option
+// had a left-over argument, as in --option=value, -ovalue, or if it was
+// midway through an option chain, as in -abc.
 func (p *Parser) RawArgs() (*RawArgs, error) {
 	if p.hasPending() {
-		return nil, ErrUnexpectedValue
+		return nil, p.unexpectedValue()
+	}
+
+	if p.state == empty && p.heldDue() {
+		p.resume()
+		return nil, p.unexpectedValue()
 	}
 	return &RawArgs{parser: p}, nil
 }
@@ -368,6 +1120,7 @@ type RawArgs struct {
 	// Current is the current argument. The Next method advances the Current argument.
 	Current Arg
 	parser  *Parser
+	pos     Position
 }
 
 // Next advances Parser's internal iterator, possibly setting
@@ -376,15 +1129,32 @@ type RawArgs struct {
 // will never result in an error (and consequently, RawArgs does not have an
 // Err method).
 func (ra *RawArgs) Next() bool {
+	step := ra.parser.beginTrace("RawArgs.Next")
+	if ra.parser.heldDue() {
+		rest, start := ra.parser.heldRest()
+		ra.Current = Value(rest)
+		ra.pos = ra.parser.span(start, len(ra.parser.tok))
+		ra.parser.endTrace(step, ra.Current, nil)
+		return true
+	}
+
 	nextTok, err := ra.parser.nextTok()
 	if err != nil {
+		ra.parser.endTrace(step, Arg{}, nil)
 		return false
 	}
 
 	ra.Current = Value(nextTok)
+	ra.pos = wholeToken(ra.parser.argv, ra.parser.idx-1)
+	ra.parser.endTrace(step, ra.Current, nil)
 	return true
 }
 
+// Position returns the position on the command line of RawArgs.Current.
+func (ra *RawArgs) Position() Position {
+	return ra.pos
+}
+
 // NextIf returns the next raw argument, only if predicate is true.
 func (ra *RawArgs) NextIf(predicate func(Arg) bool) (Arg, bool) {
 	// This is pretty unidiomatic in Go, but I'm implementing it for the compat
@@ -405,6 +1175,10 @@ func (ra *RawArgs) NextIf(predicate func(Arg) bool) (Arg, bool) {
 // Peek returns the next raw argument but does not set RawArgs.Current. It
 // returns false if the arguments have been exhausted.
 func (ra *RawArgs) Peek() (Arg, bool) {
+	if p := ra.parser; p.heldDue() {
+		return Value(p.held[len(p.held)-1].rest()), true
+	}
+
 	if ra.parser.idx >= len(ra.parser.argv) {
 		return Arg{}, false
 	}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -60,6 +61,22 @@ func (pt *parserTester) shortOk(expect rune) {
 	}
 }
 
+func (pt *parserTester) plusOk(expect rune) {
+	pt.t.Helper()
+	pt.nextOk()
+	if pt.Current != Plus(expect) {
+		pt.t.Errorf(`.Current, expect .Plus(%q), got %v`, expect, pt.Current)
+	}
+}
+
+func (pt *parserTester) plusLongOk(expect string) {
+	pt.t.Helper()
+	pt.nextOk()
+	if pt.Current != PlusLong(expect) {
+		pt.t.Errorf(`.Current, expect .PlusLong(%q), got %v`, expect, pt.Current)
+	}
+}
+
 func (pt *parserTester) positionalOk(expect string) {
 	pt.t.Helper()
 	pt.nextOk()
@@ -306,6 +323,940 @@ func TestOptionalValue(t *testing.T) {
 	noOptOk(pt)
 }
 
+func TestPlusOptions(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		pt := newTester(t, "+e ++name")
+		pt.positionalOk("+e")
+		pt.positionalOk("++name")
+		pt.emptyOk()
+	})
+
+	t.Run("clustered", func(t *testing.T) {
+		pt := newTester(t, "-e +ux -v")
+		pt.PlusOptions = true
+		pt.shortOk('e')
+		pt.plusOk('u')
+		pt.plusOk('x')
+		pt.shortOk('v')
+		pt.emptyOk()
+	})
+
+	t.Run("long", func(t *testing.T) {
+		pt := newTester(t, "++sb ++name=val ++other")
+		pt.PlusOptions = true
+		pt.plusLongOk("sb")
+		pt.plusLongOk("name")
+		pt.valueOk("val")
+		pt.plusLongOk("other")
+		pt.emptyOk()
+	})
+
+	t.Run("values", func(t *testing.T) {
+		pt := newTester(t, "+ofoo +o=bar +o baz")
+		pt.PlusOptions = true
+		pt.plusOk('o')
+		pt.valueOk("foo")
+		pt.plusOk('o')
+		pt.valueOk("bar")
+		pt.plusOk('o')
+		pt.valueOk("baz")
+		pt.emptyOk()
+	})
+
+	t.Run("lone plus is positional", func(t *testing.T) {
+		pt := newTester(t, "+ ++ -- +x")
+		pt.PlusOptions = true
+		pt.positionalOk("+")
+		pt.positionalOk("++")
+		pt.positionalOk("+x")
+		pt.emptyOk()
+	})
+
+	t.Run("stops values", func(t *testing.T) {
+		pt := newTester(t, "--cmd a b +x")
+		pt.PlusOptions = true
+		pt.longOk("cmd")
+		pt.valuesOk("a", "b")
+		pt.plusOk('x')
+	})
+}
+
+func TestNumberOptions(t *testing.T) {
+	numberOk := func(pt *parserTester, expect int) {
+		pt.t.Helper()
+		pt.nextOk()
+		if pt.Current != Number(expect) {
+			pt.t.Errorf(".Current, expect .Number(%d), got %v", expect, pt.Current)
+		}
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		pt := newTester(t, "-20")
+		pt.shortOk('2')
+		pt.shortOk('0')
+		pt.emptyOk()
+	})
+
+	t.Run("basic", func(t *testing.T) {
+		pt := newTester(t, "-20 -5 -007 -0 file")
+		pt.NumberOptions = true
+		numberOk(pt, 20)
+		numberOk(pt, 5)
+		numberOk(pt, 7)
+		numberOk(pt, 0)
+		pt.positionalOk("file")
+		pt.emptyOk()
+
+		if n := Number(20).MustInt(); n != 20 {
+			t.Errorf("Number(20).MustInt(): want 20, got %d", n)
+		}
+	})
+
+	t.Run("followed by shorts", func(t *testing.T) {
+		pt := newTester(t, "-20vq")
+		pt.NumberOptions = true
+		numberOk(pt, 20)
+		pt.shortOk('v')
+		pt.shortOk('q')
+		pt.emptyOk()
+	})
+
+	t.Run("digits later in cluster", func(t *testing.T) {
+		pt := newTester(t, "-v20 -U3")
+		pt.NumberOptions = true
+		pt.shortOk('v')
+		pt.shortOk('2')
+		pt.shortOk('0')
+		pt.shortOk('U')
+		pt.valueOk("3")
+		pt.emptyOk()
+	})
+
+	t.Run("with value", func(t *testing.T) {
+		pt := newTester(t, "-5=x -5=y")
+		pt.NumberOptions = true
+		numberOk(pt, 5)
+		pt.valueOk("x")
+		numberOk(pt, 5)
+		pt.nextErrOk(ErrUnexpectedValue)
+	})
+}
+
+func TestNormalizeLong(t *testing.T) {
+	t.Run("fold case", func(t *testing.T) {
+		pt := newTester(t, "--Verbose --DRY-RUN=yes")
+		pt.NormalizeLong = FoldCase
+		pt.longOk("verbose")
+		pt.longOk("dry-run")
+		if ds := pt.DashedCurrent(); ds != "--DRY-RUN" {
+			t.Errorf(".DashedCurrent(): want %q, got %q", "--DRY-RUN", ds)
+		}
+		pt.valueOk("yes")
+		pt.emptyOk()
+	})
+
+	t.Run("fold kebab", func(t *testing.T) {
+		pt := newTester(t, "--DryRun --dryRun --dry_run --DRY-RUN --dry-run --HTTPServer --ipv6Only")
+		pt.NormalizeLong = FoldKebab
+		for i := 0; i < 5; i++ {
+			pt.longOk("dry-run")
+		}
+		pt.longOk("http-server")
+		pt.longOk("ipv6-only")
+		pt.emptyOk()
+	})
+
+	t.Run("plus long", func(t *testing.T) {
+		pt := newTester(t, "++Name")
+		pt.PlusOptions = true
+		pt.NormalizeLong = FoldCase
+		pt.plusLongOk("name")
+		if ds := pt.DashedCurrent(); ds != "++Name" {
+			t.Errorf(".DashedCurrent(): want %q, got %q", "++Name", ds)
+		}
+	})
+
+	t.Run("other args", func(t *testing.T) {
+		pt := newTester(t, "-x Foo")
+		pt.NormalizeLong = FoldCase
+		pt.shortOk('x')
+		if ds := pt.DashedCurrent(); ds != "-x" {
+			t.Errorf(".DashedCurrent(): want %q, got %q", "-x", ds)
+		}
+		pt.positionalOk("Foo")
+	})
+}
+
+func TestConfusables(t *testing.T) {
+	t.Run("ignored by default", func(t *testing.T) {
+		pt := newTesterArgs(t, "\u2014verbose")
+		pt.positionalOk("\u2014verbose")
+		pt.emptyOk()
+	})
+
+	suggestions := map[string]string{
+		"\u2014verbose":          "--verbose",
+		"\u2013verbose":          "--verbose",
+		"\u2013v":                "-v",
+		"\u2013o=x":              "-o=x",
+		"\u2212\u2212dry-run":    "--dry-run",
+		"\uff0dx":                "-x",
+		"\u00a0--verbose":        "--verbose",
+		"\u00a0\u2010\u2010file": "--file",
+	}
+
+	for tok, expect := range suggestions {
+		t.Run("reject "+tok, func(t *testing.T) {
+			pt := newTesterArgs(t, tok)
+			pt.Confusables = ConfusablesReject
+			pt.emptyOk()
+
+			var cerr *ConfusableError
+			if !errors.As(pt.Err(), &cerr) {
+				t.Fatalf("expected *ConfusableError, got %T", pt.Err())
+			}
+
+			if cerr.Token != tok || cerr.Suggestion != expect {
+				t.Errorf("bad ConfusableError: want %q -> %q, got %q -> %q", tok, expect, cerr.Token, cerr.Suggestion)
+			}
+		})
+	}
+
+	t.Run("normalize", func(t *testing.T) {
+		pt := newTesterArgs(t, "\u2014verbose", "\u2013o", "out", "\u2014file=x", "\u2013", "\u00a0foo")
+		pt.Confusables = ConfusablesNormalize
+		pt.longOk("verbose")
+		pt.shortOk('o')
+		pt.valueOk("out")
+		pt.longOk("file")
+		pt.valueOk("x")
+		pt.positionalOk("-")
+		pt.positionalOk("\u00a0foo")
+		pt.emptyOk()
+	})
+
+	t.Run("plain tokens untouched", func(t *testing.T) {
+		pt := newTesterArgs(t, "-v", "--verbose", "caf\u00e9", "-")
+		pt.Confusables = ConfusablesReject
+		pt.shortOk('v')
+		pt.longOk("verbose")
+		pt.positionalOk("caf\u00e9")
+		pt.positionalOk("-")
+		pt.emptyOk()
+	})
+
+	t.Run("stops values", func(t *testing.T) {
+		pt := newTesterArgs(t, "--cmd", "a", "\u2014b")
+		pt.Confusables = ConfusablesNormalize
+		pt.longOk("cmd")
+		pt.valuesOk("a")
+		pt.longOk("b")
+	})
+
+	t.Run("not after double dash", func(t *testing.T) {
+		pt := newTesterArgs(t, "--", "\u2014verbose")
+		pt.Confusables = ConfusablesReject
+		pt.positionalOk("\u2014verbose")
+		pt.emptyOk()
+	})
+}
+
+func posOk(t *testing.T, desc string, got Position, index int, text string) {
+	t.Helper()
+	if got.Index != index || got.Text() != text {
+		t.Errorf("%s: want %q at argument %d, got %q at argument %d (%+v)", desc, text, index, got.Text(), got.Index, got)
+	}
+}
+
+// valuesPosOk checks positions from ValuesPositions against want, which
+// alternates argument indexes and texts.
+func valuesPosOk(t *testing.T, desc string, got []Position, want ...any) {
+	t.Helper()
+	if len(got) != len(want)/2 {
+		t.Fatalf("%s: want %d positions, got %d: %+v", desc, len(want)/2, len(got), got)
+	}
+
+	for i, pos := range got {
+		posOk(t, desc, pos, want[2*i].(int), want[2*i+1].(string))
+	}
+}
+
+func TestPositions(t *testing.T) {
+	t.Run("options and values", func(t *testing.T) {
+		pt := newTester(t, "-abc --opt=value -ofile -o=x file -- -q")
+		pt.shortOk('a')
+		posOk(t, "-a", pt.Position(), 0, "-a")
+		pt.shortOk('b')
+		posOk(t, "-b", pt.Position(), 0, "b")
+		pt.shortOk('c')
+		posOk(t, "-c", pt.CurrentPosition(), 0, "c")
+
+		pt.longOk("opt")
+		posOk(t, "--opt", pt.Position(), 1, "--opt")
+		pt.valueOk("value")
+		posOk(t, "--opt value", pt.Position(), 1, "value")
+		posOk(t, "--opt current", pt.CurrentPosition(), 1, "--opt")
+
+		pt.shortOk('o')
+		pt.valueOk("file")
+		posOk(t, "-o value", pt.Position(), 2, "file")
+
+		pt.shortOk('o')
+		pt.valueOk("x")
+		posOk(t, "-o= value", pt.Position(), 3, "x")
+
+		pt.positionalOk("file")
+		posOk(t, "positional", pt.Position(), 4, "file")
+
+		pt.positionalOk("-q")
+		posOk(t, "after --", pt.Position(), 6, "-q")
+	})
+
+	t.Run("separate values", func(t *testing.T) {
+		pt := newTester(t, "--cmd echo hi -n 5")
+		pt.longOk("cmd")
+		pt.valuesOk("echo", "hi")
+		posOk(t, "values", pt.Position(), 2, "hi")
+		pt.shortOk('n')
+		pt.valueOk("5")
+		posOk(t, "value", pt.Position(), 4, "5")
+	})
+
+	t.Run("each value", func(t *testing.T) {
+		pt := newTester(t, "--cmd echo hi --point=1 --point -1 2 3 -x -abc d")
+		pt.longOk("cmd")
+		pt.valuesOk("echo", "hi")
+		valuesPosOk(t, "Values", pt.ValuesPositions(), 1, "echo", 2, "hi")
+
+		pt.longOk("point")
+		pt.ValuesN(3)
+		valuesPosOk(t, "ValuesN with =", pt.ValuesPositions(), 3, "1")
+
+		pt.longOk("point")
+		pt.ValuesN(3)
+		valuesPosOk(t, "ValuesN", pt.ValuesPositions(), 5, "-1", 6, "2", 7, "3")
+
+		pt.shortOk('x')
+		pt.ValuesN(2)
+		valuesPosOk(t, "ValuesN short of values", pt.ValuesPositions(), 9, "-abc", 10, "d")
+
+		pt = newTester(t, "-xab c ; -y a b")
+		pt.shortOk('x')
+		pt.ValuesUntil(";")
+		valuesPosOk(t, "ValuesUntil", pt.ValuesPositions(), 0, "ab", 1, "c")
+
+		pt.shortOk('y')
+		pt.ValuesWhile(func(a Arg) bool { return a.s == "a" })
+		valuesPosOk(t, "ValuesWhile", pt.ValuesPositions(), 4, "a")
+
+		pt.Values()
+		if got := pt.ValuesPositions(); len(got) != 1 {
+			t.Errorf("Values after ValuesWhile: want 1 position, got %v", got)
+		}
+	})
+
+	t.Run("unicode", func(t *testing.T) {
+		pt := newTesterArgs(t, "-\u00e9\u00fcx", "\u2014na\u00efve=1")
+		pt.Confusables = ConfusablesNormalize
+		pt.shortOk('\u00e9')
+		pt.shortOk('\u00fc')
+		posOk(t, "multibyte short", pt.Position(), 0, "\u00fc")
+		pt.shortOk('x')
+		posOk(t, "after multibyte", pt.Position(), 0, "x")
+		pt.longOk("na\u00efve")
+		posOk(t, "fixed confusable", pt.Position(), 1, "\u2014na\u00efve")
+		pt.valueOk("1")
+		posOk(t, "fixed confusable value", pt.Position(), 1, "1")
+	})
+
+	t.Run("numbers and plus", func(t *testing.T) {
+		pt := newTester(t, "-20v +ab")
+		pt.NumberOptions = true
+		pt.PlusOptions = true
+		pt.nextOk()
+		posOk(t, "number", pt.Position(), 0, "-20")
+		pt.shortOk('v')
+		posOk(t, "after number", pt.Position(), 0, "v")
+		pt.plusOk('a')
+		posOk(t, "plus", pt.Position(), 1, "+a")
+	})
+
+	t.Run("raw args", func(t *testing.T) {
+		pt := newTester(t, "-x foo bar")
+		pt.shortOk('x')
+		args := pt.rawArgsOk()
+		args.nextArgOk("foo")
+		posOk(t, "raw", args.Position(), 1, "foo")
+	})
+
+	t.Run("string", func(t *testing.T) {
+		pos := Position{Index: 2, Start: 2, End: 3, Token: "-abc"}
+		if s := pos.String(); s != `"b" in "-abc" (argument 3)` {
+			t.Errorf("bad Position.String(): %s", s)
+		}
+
+		pos = Position{Index: 0, Start: 0, End: 4, Token: "file"}
+		if s := pos.String(); s != `"file" (argument 1)` {
+			t.Errorf("bad Position.String(): %s", s)
+		}
+	})
+}
+
+func TestCollectErrors(t *testing.T) {
+	t.Run("keeps going", func(t *testing.T) {
+		pt := newTester(t, "--foo=bar -ab=baz --quux=1 file")
+		pt.CollectErrors = true
+		pt.longOk("foo")
+		pt.shortOk('a')
+		pt.shortOk('b')
+		pt.longOk("quux")
+		pt.valueOk("1")
+		pt.positionalOk("file")
+		pt.emptyOk()
+
+		err := pt.Err()
+		if !errors.Is(err, ErrUnexpectedValue) {
+			t.Fatalf("expected ErrUnexpectedValue, got %v", err)
+		}
+
+		joined, ok := err.(interface{ Unwrap() []error })
+		if !ok {
+			t.Fatalf("expected joined errors, got %T", err)
+		}
+
+		errs := joined.Unwrap()
+		if len(errs) != 2 {
+			t.Fatalf("expected 2 errors, got %d: %v", len(errs), errs)
+		}
+
+		var argErr *ArgError
+		if !errors.As(errs[1], &argErr) || argErr.Pos.Text() != "=baz" {
+			t.Errorf("second error has wrong position: %v", errs[1])
+		}
+	})
+
+	t.Run("confusables", func(t *testing.T) {
+		pt := newTesterArgs(t, "\u2014verbose", "-q")
+		pt.CollectErrors = true
+		pt.Confusables = ConfusablesReject
+		pt.shortOk('q')
+		pt.emptyOk()
+
+		var cerr *ConfusableError
+		if !errors.As(pt.Err(), &cerr) {
+			t.Errorf("expected a ConfusableError, got %v", pt.Err())
+		}
+	})
+
+	t.Run("single error", func(t *testing.T) {
+		pt := newTester(t, "--foo=bar")
+		pt.CollectErrors = true
+		pt.longOk("foo")
+		pt.emptyOk()
+
+		var argErr *ArgError
+		if !errors.As(pt.Err(), &argErr) {
+			t.Errorf("single error should not be joined, got %T", pt.Err())
+		}
+	})
+
+	t.Run("user errors", func(t *testing.T) {
+		myErr := errors.New("--foo requires --bar")
+
+		pt := newTester(t, "--foo")
+		pt.longOk("foo")
+		pt.emptyOk()
+		if pt.Err() != nil {
+			t.Fatalf("unexpected error: %v", pt.Err())
+		}
+
+		pt.AddErr(nil)
+		pt.AddErr(myErr)
+		if pt.Err() != myErr {
+			t.Errorf("AddErr: want %v, got %v", myErr, pt.Err())
+		}
+
+		pt = newTester(t, "--foo=x")
+		pt.AddErr(myErr)
+		pt.longOk("foo")
+		pt.nextErrOk(ErrUnexpectedValue)
+		if !errors.Is(pt.Err(), myErr) {
+			t.Errorf("AddErr error lost: %v", pt.Err())
+		}
+	})
+}
+
+func TestInsert(t *testing.T) {
+	t.Run("at the start", func(t *testing.T) {
+		pt := newTester(t, "-x")
+		if err := pt.Insert("--foo", "bar"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		pt.longOk("foo")
+		pt.positionalOk("bar")
+		pt.shortOk('x')
+		pt.emptyOk()
+	})
+
+	t.Run("after an option", func(t *testing.T) {
+		argv := []string{"--ci", "file"}
+		pt := newTesterArgs(t, argv...)
+		pt.longOk("ci")
+		pt.Insert("--no-color", "--jobs=1")
+		pt.longOk("no-color")
+		pt.longOk("jobs")
+		pt.valueOk("1")
+		pt.positionalOk("file")
+		pt.emptyOk()
+
+		if argv[1] != "file" {
+			t.Errorf("Insert modified the caller's slice: %q", argv)
+		}
+	})
+
+	t.Run("mid-cluster", func(t *testing.T) {
+		pt := newTester(t, "-abc")
+		pt.shortOk('a')
+		pt.Insert("-x", "--yy")
+		pt.shortOk('x')
+		pt.longOk("yy")
+		pt.shortOk('b')
+		pt.shortOk('c')
+		pt.emptyOk()
+	})
+
+	t.Run("mid plus cluster", func(t *testing.T) {
+		pt := newTester(t, "+abc")
+		pt.PlusOptions = true
+		pt.plusOk('a')
+		pt.Insert("-x")
+		pt.shortOk('x')
+		pt.plusOk('b')
+		pt.plusOk('c')
+		pt.emptyOk()
+	})
+
+	t.Run("mid-cluster keeps the cluster", func(t *testing.T) {
+		pt := newTester(t, "-a-b")
+		pt.shortOk('a')
+		pt.Insert("-x")
+		pt.shortOk('x')
+		pt.shortOk('-')
+		posOk(t, "resumed", pt.Position(), 0, "-")
+		pt.shortOk('b')
+		pt.emptyOk()
+
+		pt = newTester(t, "-v20")
+		pt.NumberOptions = true
+		pt.shortOk('v')
+		pt.Insert("-x")
+		pt.shortOk('x')
+		pt.shortOk('2')
+		pt.shortOk('0')
+		pt.emptyOk()
+
+		pt = newTesterArgs(t, "-a—b")
+		pt.Confusables = ConfusablesNormalize
+		pt.shortOk('a')
+		pt.Insert("-x")
+		pt.shortOk('x')
+		pt.shortOk('—')
+		pt.shortOk('b')
+		pt.emptyOk()
+	})
+
+	t.Run("mid-cluster values", func(t *testing.T) {
+		pt := newTester(t, "-abc d")
+		pt.shortOk('a')
+		pt.Insert("-o")
+		pt.shortOk('o')
+		pt.valueOk("bc")
+		posOk(t, "resumed value", pt.Position(), 0, "bc")
+		pt.positionalOk("d")
+
+		pt = newTester(t, "-abc")
+		pt.shortOk('a')
+		pt.Insert("-o", "x")
+		pt.shortOk('o')
+		pt.valuesOk("x")
+		pt.shortOk('b')
+
+		pt = newTester(t, "-abc")
+		pt.shortOk('a')
+		pt.Insert("-o")
+		pt.shortOk('o')
+		pt.rawArgsErrOk()
+		pt.shortOk('b')
+	})
+
+	t.Run("mid-cluster nested", func(t *testing.T) {
+		pt := newTester(t, "-abc")
+		pt.shortOk('a')
+		pt.Insert("-xy")
+		pt.shortOk('x')
+		pt.Insert("-z")
+		pt.shortOk('z')
+		pt.shortOk('y')
+		pt.shortOk('b')
+		pt.shortOk('c')
+		pt.emptyOk()
+	})
+
+	t.Run("mid-cluster double dash", func(t *testing.T) {
+		pt := newTester(t, "-abc d")
+		pt.shortOk('a')
+		pt.Insert("--", "x")
+		pt.positionalOk("x")
+		pt.positionalOk("bc")
+		posOk(t, "rest", pt.Position(), 0, "bc")
+		pt.positionalOk("d")
+		pt.emptyOk()
+	})
+
+	t.Run("after double dash", func(t *testing.T) {
+		pt := newTester(t, "-- a")
+		pt.positionalOk("a")
+		pt.Insert("-x", "b")
+		pt.positionalOk("-x")
+		pt.positionalOk("b")
+		pt.emptyOk()
+	})
+
+	t.Run("pending value", func(t *testing.T) {
+		for _, argv := range []string{"--foo=bar", "-f=bar"} {
+			pt := newTester(t, argv)
+			pt.nextOk()
+			err := pt.Insert("x")
+			if !errors.Is(err, ErrUnexpectedValue) {
+				t.Errorf("%s: expected ErrUnexpectedValue, got %v", argv, err)
+			}
+
+			var argErr *ArgError
+			if !errors.As(err, &argErr) || argErr.Pos.Text() != "=bar" {
+				t.Errorf("%s: expected an ArgError at =bar, got %v", argv, err)
+			}
+			pt.valueOk("bar")
+			pt.emptyOk()
+		}
+	})
+
+	t.Run("checkpoint", func(t *testing.T) {
+		pt := newTester(t, "-a -b")
+		pt.shortOk('a')
+		cp := pt.Checkpoint()
+		pt.Insert("-x")
+		pt.shortOk('x')
+		pt.Restore(cp)
+		pt.shortOk('b')
+		pt.emptyOk()
+	})
+}
+
+func TestPeek(t *testing.T) {
+	peekOk := func(pt *parserTester, expect Arg) {
+		pt.t.Helper()
+		before := pt.Checkpoint()
+		got, ok := pt.Peek()
+		if !ok {
+			pt.t.Fatalf(".Peek() returned false, expected %v", expect)
+		}
+
+		if got != expect {
+			pt.t.Errorf(".Peek(): want %v, got %v", expect, got)
+		}
+
+		if pt.Current != before.current || !reflect.DeepEqual(pt.cursor, before.cursor) {
+			pt.t.Errorf(".Peek() changed the parser state")
+		}
+	}
+
+	peekEmptyOk := func(pt *parserTester) {
+		pt.t.Helper()
+		if got, ok := pt.Peek(); ok {
+			pt.t.Errorf(".Peek() unexpectedly returned %v", got)
+		}
+	}
+
+	t.Run("mid-cluster", func(t *testing.T) {
+		pt := newTester(t, "-abc --foo")
+		peekOk(pt, Short('a'))
+		pt.shortOk('a')
+		peekOk(pt, Short('b'))
+		pt.shortOk('b')
+		pt.shortOk('c')
+		peekOk(pt, Long("foo"))
+		pt.longOk("foo")
+		peekEmptyOk(pt)
+		pt.emptyOk()
+	})
+
+	t.Run("after double dash", func(t *testing.T) {
+		pt := newTester(t, "-- -x")
+		peekOk(pt, Value("-x"))
+		pt.positionalOk("-x")
+	})
+
+	t.Run("optional value", func(t *testing.T) {
+		pt := newTester(t, "--color always --color -x")
+		for _, expect := range []string{"always", ""} {
+			pt.longOk("color")
+			val := ""
+			if next, ok := pt.Peek(); ok && !next.IsOption() {
+				arg, _ := pt.Value()
+				val = arg.String()
+			}
+
+			if val != expect {
+				t.Errorf("optional value: want %q, got %q", expect, val)
+			}
+		}
+		pt.shortOk('x')
+	})
+
+	t.Run("pending value", func(t *testing.T) {
+		pt := newTester(t, "--foo=bar")
+		pt.longOk("foo")
+		peekEmptyOk(pt)
+		if pt.Err() != nil {
+			t.Errorf(".Peek() left an error behind: %v", pt.Err())
+		}
+		pt.valueOk("bar")
+	})
+}
+
+func argStrings(args []Arg) []string {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		strs[i] = arg.String()
+	}
+	return strs
+}
+
+func valuesErrOk(t *testing.T, err error, found, want int) {
+	t.Helper()
+
+	var verr *ValuesError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValuesError, got %v", err)
+	}
+
+	if !errors.Is(err, ErrNoValue) {
+		t.Errorf("ValuesError should wrap ErrNoValue")
+	}
+
+	if verr.Found != found || verr.Want != want {
+		t.Errorf("bad ValuesError: want %d/%d, got %d/%d", found, want, verr.Found, verr.Want)
+	}
+}
+
+func TestValuesN(t *testing.T) {
+	tests := []struct {
+		argv   string
+		n      int
+		expect []string
+	}{
+		{"--point 1 -2 3 -q", 3, []string{"1", "-2", "3"}},
+		{"-p1 -2 3 -q", 3, []string{"1", "-2", "3"}},
+		{"--point=1 2 3", 1, []string{"1"}},
+		{"--point -- 1", 2, []string{"--", "1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.argv, func(t *testing.T) {
+			pt := newTester(t, test.argv)
+			pt.nextOk()
+			vals, err := pt.ValuesN(test.n)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := argStrings(vals); !reflect.DeepEqual(got, test.expect) {
+				t.Errorf("want %q, got %q", test.expect, got)
+			}
+		})
+	}
+
+	t.Run("equals limits", func(t *testing.T) {
+		pt := newTester(t, "--point=1 2 3")
+		pt.longOk("point")
+		vals, err := pt.ValuesN(3)
+		valuesErrOk(t, err, 1, 3)
+		if len(vals) != 1 {
+			t.Errorf("expected the one value found, got %v", vals)
+		}
+		pt.positionalOk("2")
+	})
+
+	t.Run("too few", func(t *testing.T) {
+		pt := newTester(t, "--point 1 2")
+		pt.longOk("point")
+		_, err := pt.ValuesN(3)
+		valuesErrOk(t, err, 2, 3)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		for _, n := range []int{0, -1} {
+			pt := newTester(t, "--point 1")
+			pt.longOk("point")
+			vals, err := pt.ValuesN(n)
+			if len(vals) != 0 || err != nil {
+				t.Errorf("ValuesN(%d): want no values, got %v, %v", n, vals, err)
+			}
+			pt.positionalOk("1")
+		}
+	})
+}
+
+func TestValuesUntil(t *testing.T) {
+	t.Run("find style", func(t *testing.T) {
+		pt := newTester(t, "-exec rm -f {} ; -print")
+		pt.shortOk('e')
+		vals, err := pt.ValuesUntil(";")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		// -exec is a cluster here, so "xec" is the first value.
+		expect := []string{"xec", "rm", "-f", "{}"}
+		if got := argStrings(vals); !reflect.DeepEqual(got, expect) {
+			t.Errorf("want %q, got %q", expect, got)
+		}
+		posOk(t, "last value", pt.Position(), 3, "{}")
+		pt.shortOk('p')
+	})
+
+	t.Run("equals", func(t *testing.T) {
+		pt := newTester(t, "--exec=ls ;")
+		pt.longOk("exec")
+		vals, err := pt.ValuesUntil(";")
+		if err != nil || len(vals) != 1 || vals[0] != Value("ls") {
+			t.Errorf("unexpected result: %v, %v", vals, err)
+		}
+		pt.positionalOk(";")
+	})
+
+	t.Run("no terminator", func(t *testing.T) {
+		pt := newTester(t, "--exec ls -l")
+		pt.longOk("exec")
+		vals, err := pt.ValuesUntil(";")
+		valuesErrOk(t, err, 2, 1)
+		if len(vals) != 2 {
+			t.Errorf("expected the values found, got %v", vals)
+		}
+
+		var verr *ValuesError
+		errors.As(err, &verr)
+		if verr.Terminator != ";" {
+			t.Errorf("bad terminator in error: %q", verr.Terminator)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		pt := newTester(t, "--exec ; x")
+		pt.longOk("exec")
+		_, err := pt.ValuesUntil(";")
+		valuesErrOk(t, err, 0, 1)
+		pt.positionalOk("x")
+	})
+}
+
+func TestValuesWhile(t *testing.T) {
+	isNumber := func(a Arg) bool {
+		_, err := a.Int()
+		return err == nil
+	}
+
+	t.Run("predicate", func(t *testing.T) {
+		pt := newTester(t, "--nums 1 -2 3 x")
+		pt.longOk("nums")
+		vals, err := pt.ValuesWhile(isNumber)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		expect := []string{"1", "-2", "3"}
+		if got := argStrings(vals); !reflect.DeepEqual(got, expect) {
+			t.Errorf("want %q, got %q", expect, got)
+		}
+		pt.positionalOk("x")
+	})
+
+	t.Run("attached", func(t *testing.T) {
+		pt := newTester(t, "-nx 1 2")
+		pt.shortOk('n')
+		vals, _ := pt.ValuesWhile(isNumber)
+		expect := []string{"x", "1", "2"}
+		if got := argStrings(vals); !reflect.DeepEqual(got, expect) {
+			t.Errorf("want %q, got %q", expect, got)
+		}
+	})
+
+	t.Run("equals", func(t *testing.T) {
+		pt := newTester(t, "--nums=x 1")
+		pt.longOk("nums")
+		vals, _ := pt.ValuesWhile(isNumber)
+		if len(vals) != 1 || vals[0] != Value("x") {
+			t.Errorf("unexpected values: %v", vals)
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		pt := newTester(t, "--nums x")
+		pt.longOk("nums")
+		_, err := pt.ValuesWhile(isNumber)
+		valuesErrOk(t, err, 0, 1)
+		pt.positionalOk("x")
+	})
+
+	t.Run("double dash", func(t *testing.T) {
+		pt := newTester(t, "--w -- a")
+		pt.longOk("w")
+		vals, _ := pt.ValuesWhile(func(Arg) bool { return true })
+		expect := []string{"--"}
+		if got := argStrings(vals); !reflect.DeepEqual(got, expect) {
+			t.Errorf("want %q, got %q", expect, got)
+		}
+		pt.positionalOk("a")
+	})
+}
+
+func TestOptionalChoice(t *testing.T) {
+	choices := []string{"auto", "always", "never"}
+
+	pt := newTester(t, "--color --color=never --color auto -c=always --color=sometimes")
+	for _, expect := range []string{"always", "never", "always"} {
+		pt.nextOk()
+		when, err := pt.OptionalChoice(choices, "always")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if when != expect {
+			t.Errorf(".OptionalChoice(): want %q, got %q", expect, when)
+		}
+	}
+
+	pt.positionalOk("auto")
+
+	pt.shortOk('c')
+	if when, _ := pt.OptionalChoice(choices, "auto"); when != "always" {
+		t.Errorf(".OptionalChoice(): want %q, got %q", "always", when)
+	}
+
+	pt.longOk("color")
+	_, err := pt.OptionalChoice(choices, "always")
+	var cerr *ChoiceError
+	if !errors.As(err, &cerr) || cerr.Value != "sometimes" || !reflect.DeepEqual(cerr.Choices, choices) {
+		t.Fatalf("bad ChoiceError: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "auto, always, never") {
+		t.Errorf("error doesn't list the choices: %s", err)
+	}
+
+	pt.emptyOk()
+}
+
 type rawArgsTester struct {
 	*RawArgs
 	t *testing.T
@@ -427,6 +1378,13 @@ func TestRawArgs(t *testing.T) {
 		pt.longOk("foo")
 		pt.rawArgsErrOk()
 
+		var argErr *ArgError
+		if _, err := pt.RawArgs(); !errors.As(err, &argErr) {
+			t.Errorf("expected an ArgError, got %v", err)
+		} else {
+			posOk(t, "RawArgs error", argErr.Pos, 0, "=bar")
+		}
+
 		// But we can continue after eating the value
 		pt.valueOk("bar")
 		args := pt.rawArgsOk()
@@ -486,6 +1444,255 @@ func TestArgConversions(t *testing.T) {
 
 }
 
+func TestIntConversions(t *testing.T) {
+	a := Value("-128")
+	runConvOk(t, a, "int8", int8(-128), a.Int8, a.MustInt8)
+	runConvOk(t, a, "int16", int16(-128), a.Int16, a.MustInt16)
+	runConvOk(t, a, "int32", int32(-128), a.Int32, a.MustInt32)
+	runConvErr(t, a, "bad uint8", a.Uint8, a.MustUint8)
+
+	a = Value("255")
+	runConvOk(t, a, "uint8", uint8(255), a.Uint8, a.MustUint8)
+	runConvOk(t, a, "uint16", uint16(255), a.Uint16, a.MustUint16)
+	runConvOk(t, a, "uint32", uint32(255), a.Uint32, a.MustUint32)
+	runConvErr(t, a, "int8 overflow", a.Int8, a.MustInt8)
+
+	auto := map[string]int{
+		"0xff":      255,
+		"0XFF":      255,
+		"0o755":     493,
+		"0755":      493,
+		"0b101":     5,
+		"1_000_000": 1000000,
+		"-0x10":     -16,
+		"42":        42,
+	}
+
+	for in, expect := range auto {
+		a := Value(in)
+		runConvOk(t, a, "int auto "+in, expect, a.IntAuto, a.MustIntAuto)
+		runConvOk(t, a, "int64 auto "+in, int64(expect), a.Int64Auto, a.MustInt64Auto)
+		if expect >= 0 {
+			runConvOk(t, a, "uint auto "+in, uint(expect), a.UintAuto, a.MustUintAuto)
+			runConvOk(t, a, "uint64 auto "+in, uint64(expect), a.Uint64Auto, a.MustUint64Auto)
+		}
+	}
+
+	a = Value("0xff")
+	runConvErr(t, a, "no prefix without auto", a.Int, a.MustInt)
+
+	a = Value("1_000")
+	runConvErr(t, a, "no separators without auto", a.Int, a.MustInt)
+
+	rangeErrs := map[string]func() error{
+		"int8":   func() error { _, err := Value("300").Int8(); return err },
+		"uint16": func() error { _, err := Value("65536").Uint16(); return err },
+		"int64":  func() error { _, err := Value("9223372036854775808").Int64(); return err },
+		"int":    func() error { _, err := Value("99999999999999999999").Int(); return err },
+	}
+
+	for typ, conv := range rangeErrs {
+		t.Run(typ+" range error", func(t *testing.T) {
+			err := conv()
+			var rerr *RangeError
+			if !errors.As(err, &rerr) || rerr.Type != typ {
+				t.Fatalf("expected RangeError for %s, got %v", typ, err)
+			}
+
+			if !errors.Is(err, strconv.ErrRange) {
+				t.Errorf("RangeError should wrap strconv.ErrRange")
+			}
+
+			if !strings.Contains(err.Error(), "out of range for "+typ) {
+				t.Errorf("error doesn't name the type: %s", err)
+			}
+
+			var numErr *strconv.NumError
+			if !errors.As(err, &numErr) || !strings.Contains(err.Error(), "strconv.Parse") {
+				t.Errorf("RangeError should wrap the strconv.NumError: %s", err)
+			}
+		})
+	}
+}
+
+func TestBytes(t *testing.T) {
+	good := map[string]uint64{
+		"0":         0,
+		"512":       512,
+		"512B":      512,
+		"1k":        1024,
+		"1K":        1024,
+		"1KiB":      1024,
+		"1kib":      1024,
+		"1kB":       1000,
+		"1KB":       1000,
+		"512MiB":    512 << 20,
+		"1.5G":      3 << 29,
+		"1.5GB":     1500000000,
+		".5M":       1 << 19,
+		"2 MiB":     2 << 20,
+		"1.0001kB":  1000,
+		"15EiB":     15 << 60,
+		"18EB":      18e18,
+		"1.5B":      1,
+		"3.999999T": 4398045411592,
+	}
+
+	for in, expect := range good {
+		a := Value(in)
+		runConvOk(t, a, in, expect, a.Bytes, a.MustBytes)
+	}
+
+	for _, in := range []string{"", "MiB", "-1K", "1.2.3K", "1 KiBs", "1X", "0x10", " 1"} {
+		a := Value(in)
+		runConvErr(t, a, "bad "+in, a.Bytes, a.MustBytes)
+	}
+
+	for _, in := range []string{"16EiB", "18446744073709551616", "18.5EB", "100000000000000000000kB"} {
+		_, err := Value(in).Bytes()
+		var rerr *RangeError
+		if !errors.As(err, &rerr) {
+			t.Errorf("%s: expected RangeError, got %v", in, err)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		0:           "0B",
+		1000:        "1000B",
+		1024:        "1KiB",
+		1536:        "1.5KiB",
+		1280:        "1.25KiB",
+		1100:        "1100B",
+		512 << 20:   "512MiB",
+		3 << 29:     "1.5GiB",
+		1<<64 - 1:   "18446744073709551615B",
+		15 << 60:    "15EiB",
+		1<<40 + 512: "1073741824.5KiB",
+		1<<40 + 1:   "1099511627777B",
+	}
+
+	for n, expect := range tests {
+		got := FormatBytes(n)
+		if got != expect {
+			t.Errorf("FormatBytes(%d): want %q, got %q", n, expect, got)
+		}
+
+		if back := Value(got).MustBytes(); back != n {
+			t.Errorf("FormatBytes(%d) doesn't round-trip: got %d", n, back)
+		}
+	}
+}
+
+func TestIntRanges(t *testing.T) {
+	tests := map[string]string{
+		"0-3,8,10-11": "0-3,8,10-11",
+		"3,0-2,2-4":   "0-4",
+		"8,8,8":       "8",
+		"5-":          "5-63",
+		"60-":         "60-63",
+		"63-":         "63",
+		"0-62:10":     "0-60:10",
+		"1-10:3":      "1-10:3",
+		"1-9:3":       "1-7:3",
+		"4-5:2":       "4",
+		"0-4:2,1-3":   "0-4:2,1-3",
+		"0-4:2,0-4:2": "0-4:2",
+		"007":         "7",
+	}
+
+	for in, expect := range tests {
+		t.Run(in, func(t *testing.T) {
+			ranges, err := Value(in).IntRanges(0, 63)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			strs := make([]string, len(ranges))
+			for i, r := range ranges {
+				strs[i] = r.String()
+			}
+
+			if got := strings.Join(strs, ","); got != expect {
+				t.Errorf("want %s, got %s", expect, got)
+			}
+		})
+	}
+
+	for _, in := range []string{"", "a", "1-b", "-3", "1--3", "3-1", "1:2", "1-5:0", "1-5:", "1-5:-1", "1,,2", " 1", "+1"} {
+		t.Run("bad "+in, func(t *testing.T) {
+			_, err := Value(in).IntRanges(0, 63)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected ParseError, got %v", err)
+			}
+		})
+	}
+
+	for _, in := range []string{"64", "0-64", "1,2,100-", "99999999999999999999"} {
+		_, err := Value(in).IntRanges(0, 63)
+		if !errors.Is(err, strconv.ErrRange) {
+			t.Errorf("%s: expected range error, got %v", in, err)
+		}
+	}
+
+	_, err := Value("8000-8100,80").IntRanges(1024, 65535)
+	if err == nil || !strings.Contains(err.Error(), `"80": want numbers from 1024 to 65535`) {
+		t.Errorf("error should name the bad element and the bounds, got %v", err)
+	}
+
+	r := IntRange{Lo: 2, Hi: 10, Step: 4}
+	for n, expect := range map[int]bool{2: true, 6: true, 10: true, 4: false, 14: false, -2: false} {
+		if r.Contains(n) != expect {
+			t.Errorf("%s contains %d: want %t", r, n, expect)
+		}
+	}
+
+	for _, r := range []IntRange{{Lo: 1, Hi: 3}, {Lo: 1, Hi: 3, Step: -2}} {
+		if !r.Contains(2) || !reflect.DeepEqual(r.Values(), []int{1, 2, 3}) || r.String() != "1-3" {
+			t.Errorf("%+v should be treated as a step of 1: %v, %s", r, r.Values(), r)
+		}
+	}
+
+	if vals := (IntRange{}).Values(); !reflect.DeepEqual(vals, []int{0}) {
+		t.Errorf("zero IntRange: want [0], got %v", vals)
+	}
+
+	if vals := (IntRange{Lo: 3, Hi: 1}).Values(); len(vals) != 0 {
+		t.Errorf("empty IntRange: want no values, got %v", vals)
+	}
+}
+
+func TestIntSet(t *testing.T) {
+	a := Value("10-11,0-3,8,2,0-20:5")
+	set := a.MustIntSet(0, 63)
+	expect := []int{0, 1, 2, 3, 5, 8, 10, 11, 15, 20}
+	if !reflect.DeepEqual(set, expect) {
+		t.Errorf("want %v, got %v", expect, set)
+	}
+
+	a = Value("0-64")
+	if _, err := a.IntSet(0, 63); err == nil {
+		t.Errorf("expected bounds error")
+	}
+}
+
+func TestShard(t *testing.T) {
+	index, count, err := Value("2/5").Shard()
+	if err != nil || index != 2 || count != 5 {
+		t.Errorf("want 2, 5, got %d, %d, %v", index, count, err)
+	}
+
+	for _, in := range []string{"", "2", "2/", "/5", "0/5", "6/5", "1/0", "a/b", "-1/5", "2/5/6"} {
+		_, _, err := Value(in).Shard()
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: expected ParseError, got %v", in, err)
+		}
+	}
+}
+
 func runConvOk[T comparable](
 	t *testing.T,
 	a Arg,
@@ -540,7 +1747,23 @@ func TestDashedString(t *testing.T) {
 		t.Errorf(".DashedString returned weird string: want %q, got %q", "-a", ds)
 	}
 
+	if Long("file").IsOption() != true || Value("file").IsOption() != false {
+		t.Errorf(".IsOption returned the wrong thing")
+	}
+
 	if ds := Long("file").DashedString(); ds != "--file" {
 		t.Errorf(".DashedString returned weird string: want %q, got %q", "--file", ds)
 	}
+
+	if ds := Number(20).DashedString(); ds != "-20" {
+		t.Errorf(".DashedString returned weird string: want %q, got %q", "-20", ds)
+	}
+
+	if ds := Plus('e').DashedString(); ds != "+e" {
+		t.Errorf(".DashedString returned weird string: want %q, got %q", "+e", ds)
+	}
+
+	if ds := PlusLong("sb").DashedString(); ds != "++sb" {
+		t.Errorf(".DashedString returned weird string: want %q, got %q", "++sb", ds)
+	}
 }
@@ -0,0 +1,145 @@
+package lexopt
+
+// List splits the argument into a list at each sep, as for --tags a,b,c or
+// --paths /x:/y. A backslash escapes the next character, and single or
+// double quotes protect the text between them, so that elements can contain
+// sep, as in --tags 'a\,b,"c,d"'; the quotes and backslashes are removed.
+// Empty elements are kept, but an empty argument is an empty list. For other
+// policies or element types, use a [ListOf].
+func (a Arg) List(sep string) ([]string, error) {
+	return ListOf[string]{Sep: sep}.Parse(a)
+}
+
+// MustList is like [Arg.List], but panics if the conversion fails.
+func (a Arg) MustList(sep string) []string { return must(a.List(sep)) }
+
+// EmptyPolicy says what a [ListOf] does with empty elements, like the middle
+// one in a,,b.
+type EmptyPolicy int
+
+const (
+	// EmptyKeep keeps empty elements, as strings.Split does. This is the
+	// default.
+	EmptyKeep EmptyPolicy = iota
+
+	// EmptySkip silently drops empty elements.
+	EmptySkip
+
+	// EmptyReject makes [ListOf.Parse] fail with a [ParseError].
+	EmptyReject
+)
+
+// ListOf describes a delimited list of values, each converted to a T. The
+// quoting rules are those of [Arg.List]. For example:
+//
+//	ports := lexopt.ListOf[uint16]{Trim: true, Convert: lexopt.Arg.Uint16}
+//	list, err := ports.Parse(val) // 80, 443 => []uint16{80, 443}
+type ListOf[T any] struct {
+	// Sep separates the elements. If empty, it is ",".
+	Sep string
+
+	// Trim removes unquoted white space around each element.
+	Trim bool
+
+	// Empty says what to do with empty elements. An element is empty if
+	// nothing is left after trimming and unquoting, so with EmptyReject, ""
+	// is rejected too.
+	Empty EmptyPolicy
+
+	// Convert converts each element. If nil, T must be string.
+	Convert func(Arg) (T, error)
+}
+
+// Parse splits a into a list and converts each element. An empty argument is
+// an empty list.
+func (l ListOf[T]) Parse(a Arg) ([]T, error) {
+	if a.s == "" {
+		return nil, nil
+	}
+
+	sep := l.Sep
+	if sep == "" {
+		sep = ","
+	}
+
+	fields, err := splitQuoted(a.s, sep, -1, false)
+	if err != nil {
+		return nil, &ParseError{Value: a.s, Want: "a " + sep + "-separated list with balanced quotes", Err: err}
+	}
+
+	list := make([]T, 0, len(fields))
+	for _, field := range fields {
+		if l.Trim {
+			field = trimUnquoted(field)
+		}
+
+		elem, err := splitQuoted(field, "", 1, true)
+		if err != nil {
+			return nil, &ParseError{Value: a.s, Want: "a " + sep + "-separated list with balanced quotes", Err: err}
+		}
+
+		if elem[0] == "" {
+			switch l.Empty {
+			case EmptySkip:
+				continue
+			case EmptyReject:
+				return nil, &ParseError{Value: a.s, Want: "a " + sep + "-separated list without empty elements"}
+			}
+		}
+
+		val, err := l.convert(Value(elem[0]))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, val)
+	}
+
+	return list, nil
+}
+
+// MustParse is like [ListOf.Parse], but panics if the conversion fails.
+func (l ListOf[T]) MustParse(a Arg) []T { return must(l.Parse(a)) }
+
+// convert converts an element with Convert, if it's set.
+func (l ListOf[T]) convert(a Arg) (T, error) {
+	if l.Convert != nil {
+		return l.Convert(a)
+	}
+
+	v, ok := any(a.s).(T)
+	if !ok {
+		panic("lexopt: ListOf needs a Convert function for non-string elements")
+	}
+	return v, nil
+}
+
+// ListCollector gathers the elements of lists from repeated options, like
+// --tag a,b --tag c, or from all the values returned by [Parser.Values], into
+// a single list, in order:
+//
+//	tags := lexopt.ListCollector[string]{ListOf: lexopt.ListOf[string]{Trim: true}}
+//	...
+//	case lexopt.Long("tag"):
+//		vals, err := p.Values()
+//		...
+//		err = tags.Add(vals...)
+type ListCollector[T any] struct {
+	ListOf[T]
+
+	// Items are the elements collected so far.
+	Items []T
+}
+
+// Add splits each of args as a list and appends its elements to Items. On
+// error, the elements of the earlier args have already been added.
+func (c *ListCollector[T]) Add(args ...Arg) error {
+	for _, a := range args {
+		list, err := c.Parse(a)
+		if err != nil {
+			return err
+		}
+		c.Items = append(c.Items, list...)
+	}
+
+	return nil
+}
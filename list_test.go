@@ -0,0 +1,149 @@
+package lexopt
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	good := map[string][]string{
+		"":                 nil,
+		"a":                {"a"},
+		"a,b,c":            {"a", "b", "c"},
+		"a,,b":             {"a", "", "b"},
+		"a,":               {"a", ""},
+		`a\,b,c`:           {"a,b", "c"},
+		`"a,b",c`:          {"a,b", "c"},
+		`'a,"b',c`:         {`a,"b`, "c"},
+		`x"y,z"`:           {"xy,z"},
+		`back\\slash,q\"`:  {`back\slash`, `q"`},
+		`'C:\dir','D:\'`:   {`C:\dir`, `D:\`},
+		" spaced , out ":   {" spaced ", " out "},
+		`"",b`:             {"", "b"},
+		`"say \"hi\"",bye`: {`say "hi"`, "bye"},
+	}
+
+	for in, expect := range good {
+		t.Run(in, func(t *testing.T) {
+			got, err := Value(in).List(",")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, expect) {
+				t.Errorf("want %q, got %q", expect, got)
+			}
+		})
+	}
+
+	if got := Value(`/x:/y\:z`).MustList(":"); !reflect.DeepEqual(got, []string{"/x", "/y:z"}) {
+		t.Errorf("colon separator: got %q", got)
+	}
+
+	if got := Value("a::b:c").MustList("::"); !reflect.DeepEqual(got, []string{"a", "b:c"}) {
+		t.Errorf("multi-byte separator: got %q", got)
+	}
+
+	for _, in := range []string{`"a,b`, `a'`, `a\`} {
+		_, err := Value(in).List(",")
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%s: expected ParseError, got %v", in, err)
+		}
+	}
+}
+
+func TestListOf(t *testing.T) {
+	trim := ListOf[string]{Trim: true}
+	if got := trim.MustParse(Value(` a , " b " ,c`)); !reflect.DeepEqual(got, []string{"a", " b ", "c"}) {
+		t.Errorf("Trim: got %q", got)
+	}
+
+	// Escaped and quoted white space is kept.
+	if got := trim.MustParse(Value(`a\ ,b`)); !reflect.DeepEqual(got, []string{"a ", "b"}) {
+		t.Errorf("Trim escaped: got %q", got)
+	}
+	if got := trim.MustParse(Value(` \ a\\ , 'b ' ,"\ c\" " `)); !reflect.DeepEqual(got, []string{" a\\", "b ", ` c" `}) {
+		t.Errorf("Trim quoted: got %q", got)
+	}
+
+	skip := ListOf[string]{Trim: true, Empty: EmptySkip}
+	if got := skip.MustParse(Value("a, ,,b,")); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("EmptySkip: got %q", got)
+	}
+
+	reject := ListOf[string]{Empty: EmptyReject}
+	for _, in := range []string{"a,,b", "a,", `a,""`} {
+		_, err := reject.Parse(Value(in))
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("EmptyReject %s: expected ParseError, got %v", in, err)
+		}
+	}
+
+	ports := ListOf[uint16]{Trim: true, Convert: Arg.Uint16}
+	if got := ports.MustParse(Value("80, 443")); !reflect.DeepEqual(got, []uint16{80, 443}) {
+		t.Errorf("Convert: got %v", got)
+	}
+
+	if _, err := ports.Parse(Value("80,http")); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("expected conversion error, got %v", err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected panic without Convert")
+			}
+		}()
+
+		ListOf[int]{}.Parse(Value("1"))
+	}()
+}
+
+func TestListCollector(t *testing.T) {
+	p := newTesterArgs(t, "--tag", "a,b", "--tag=c", "--tags", "d", "e,f", "-x")
+
+	tags := ListCollector[string]{ListOf: ListOf[string]{Empty: EmptySkip}}
+
+	for i := 0; i < 2; i++ {
+		p.longOk("tag")
+		val, err := p.Value()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if err := tags.Add(val); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	p.longOk("tags")
+	vals, err := p.Values()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := tags.Add(vals...); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	p.shortOk('x')
+
+	expect := []string{"a", "b", "c", "d", "e", "f"}
+	if !reflect.DeepEqual(tags.Items, expect) {
+		t.Errorf("want %q, got %q", expect, tags.Items)
+	}
+
+	nums := ListCollector[int]{ListOf: ListOf[int]{Convert: Arg.Int}}
+	err = nums.Add(Value("1,2"), Value("x"), Value("3"))
+	if err == nil {
+		t.Fatalf("expected conversion error")
+	}
+
+	if !reflect.DeepEqual(nums.Items, []int{1, 2}) {
+		t.Errorf("elements before the error should be kept, got %v", nums.Items)
+	}
+}
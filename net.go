@@ -0,0 +1,199 @@
+package lexopt
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Addr converts Arg to an IPv4 or IPv6 address, like 192.0.2.1 or
+// 2001:db8::1.
+func (a Arg) Addr() (netip.Addr, error) {
+	addr, err := netip.ParseAddr(a.s)
+	if err != nil {
+		return netip.Addr{}, &ParseError{Value: a.s, Want: "an IP address, like 192.0.2.1 or 2001:db8::1", Err: err}
+	}
+	return addr, nil
+}
+
+// MustAddr is like [Arg.Addr], but panics if the conversion fails.
+func (a Arg) MustAddr() netip.Addr { return must(a.Addr()) }
+
+// Prefix converts Arg to an IP network in CIDR notation, like 10.0.0.0/8. The
+// address is kept as given; use [netip.Prefix.Masked] to clear the host bits.
+func (a Arg) Prefix() (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(a.s)
+	if err != nil {
+		return netip.Prefix{}, &ParseError{Value: a.s, Want: "an IP prefix, like 10.0.0.0/8 or 2001:db8::/32", Err: err}
+	}
+	return prefix, nil
+}
+
+// MustPrefix is like [Arg.Prefix], but panics if the conversion fails.
+func (a Arg) MustPrefix() netip.Prefix { return must(a.Prefix()) }
+
+// AddrPort converts Arg to an IP address and port, like 192.0.2.1:80 or
+// [2001:db8::1]:80. Use [Arg.HostPort] to also allow hostnames or a default
+// port.
+func (a Arg) AddrPort() (netip.AddrPort, error) {
+	ap, err := netip.ParseAddrPort(a.s)
+	if err != nil {
+		return netip.AddrPort{}, &ParseError{Value: a.s, Want: "an address and port, like 192.0.2.1:80 or [2001:db8::1]:80", Err: err}
+	}
+	return ap, nil
+}
+
+// MustAddrPort is like [Arg.AddrPort], but panics if the conversion fails.
+func (a Arg) MustAddrPort() netip.AddrPort { return must(a.AddrPort()) }
+
+// HostPort is a host, either a hostname or an IP address, and a port, as
+// returned by [Arg.HostPort].
+type HostPort struct {
+	Host string // a hostname or IP address, without brackets; empty for any
+	Port uint16
+}
+
+// String returns the host and port in a form suitable for [net.Dial], with
+// brackets around IPv6 addresses.
+func (hp HostPort) String() string {
+	return net.JoinHostPort(hp.Host, strconv.FormatUint(uint64(hp.Port), 10))
+}
+
+// HostPort converts Arg to a [HostPort]. It accepts a hostname or IP address,
+// optionally followed by a colon and a port, like example.com:443 or
+// [2001:db8::1]:443; an IPv6 address only needs brackets if it has a port.
+// The host may be left out, as in :8080, which means any address when
+// listening.
+//
+// If the port is left out, it is defaultPort; a defaultPort of 0 means the
+// port is required.
+func (a Arg) HostPort(defaultPort uint16) (HostPort, error) {
+	want := "a host and port, like example.com:443 or [2001:db8::1]:443"
+	if defaultPort != 0 {
+		want = "a host and optional port, like example.com or example.com:443"
+	}
+
+	fail := func(err error) (HostPort, error) {
+		return HostPort{}, &ParseError{Value: a.s, Want: want, Err: err}
+	}
+
+	if a.s == "" {
+		return fail(errors.New("empty address"))
+	}
+
+	host, port, hasPort := a.s, "", false
+	switch {
+	case strings.HasPrefix(a.s, "["):
+		end := strings.Index(a.s, "]")
+		if end < 0 {
+			return fail(errors.New("missing ]"))
+		}
+
+		host = a.s[1:end]
+		rest := a.s[end+1:]
+		if rest != "" {
+			if !strings.HasPrefix(rest, ":") {
+				return fail(errors.New("unexpected text after ]"))
+			}
+			port, hasPort = rest[1:], true
+		}
+
+		if addr, err := netip.ParseAddr(host); err != nil || !addr.Is6() {
+			return fail(errors.New("only IPv6 addresses go in brackets"))
+		}
+
+	case strings.Count(a.s, ":") == 1:
+		host, port, hasPort = strings.Cut(a.s, ":")
+
+	case strings.Count(a.s, ":") > 1:
+		// A bare IPv6 address, which can't have a port.
+		if _, err := netip.ParseAddr(a.s); err != nil {
+			return fail(errors.New("IPv6 addresses with a port need brackets"))
+		}
+	}
+
+	if host != "" && !isHostname(host) {
+		if _, err := netip.ParseAddr(host); err != nil {
+			return fail(errors.New("invalid host"))
+		}
+	}
+
+	if !hasPort {
+		if defaultPort == 0 {
+			return fail(errors.New("missing port"))
+		}
+		return HostPort{Host: host, Port: defaultPort}, nil
+	}
+
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return fail(errors.New("invalid port"))
+	}
+
+	return HostPort{Host: host, Port: uint16(n)}, nil
+}
+
+// MustHostPort is like [Arg.HostPort], but panics if the conversion fails.
+func (a Arg) MustHostPort(defaultPort uint16) HostPort { return must(a.HostPort(defaultPort)) }
+
+// isHostname reports whether s is a valid DNS hostname: dot-separated labels
+// of letters, digits and hyphens, where no label starts or ends with a hyphen.
+// A trailing dot, as in a fully qualified name, is allowed.
+func isHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+
+	// Something like 1.2.3 is a malformed address, not a hostname.
+	last := s[strings.LastIndex(s, ".")+1:]
+	return strings.Trim(last, "0123456789") != ""
+}
+
+// URL converts Arg to an absolute [url.URL], like https://example.com/path.
+// If any schemes are given, the URL must use one of them; schemes are matched
+// case-insensitively.
+func (a Arg) URL(schemes ...string) (*url.URL, error) {
+	want := "an absolute URL, like https://example.com"
+	if len(schemes) > 0 {
+		want = "a URL with scheme " + strings.Join(schemes, " or ")
+	}
+
+	u, err := url.Parse(a.s)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return nil, &ParseError{Value: a.s, Want: want, Err: err}
+	}
+
+	if u.Scheme == "" || (u.Host == "" && u.Opaque == "" && u.Path == "") {
+		return nil, &ParseError{Value: a.s, Want: want}
+	}
+
+	if len(schemes) > 0 && !slices.ContainsFunc(schemes, func(s string) bool { return strings.EqualFold(s, u.Scheme) }) {
+		return nil, &ParseError{Value: a.s, Want: want}
+	}
+
+	return u, nil
+}
+
+// MustURL is like [Arg.URL], but panics if the conversion fails.
+func (a Arg) MustURL(schemes ...string) *url.URL { return must(a.URL(schemes...)) }
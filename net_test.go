@@ -0,0 +1,142 @@
+package lexopt
+
+import (
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestAddrConversions(t *testing.T) {
+	a := Value("192.0.2.1")
+	runConvOk(t, a, "ipv4", netip.MustParseAddr("192.0.2.1"), a.Addr, a.MustAddr)
+
+	a = Value("2001:db8::1")
+	runConvOk(t, a, "ipv6", netip.MustParseAddr("2001:db8::1"), a.Addr, a.MustAddr)
+
+	a = Value("10.0.0.0/8")
+	runConvOk(t, a, "prefix", netip.MustParsePrefix("10.0.0.0/8"), a.Prefix, a.MustPrefix)
+	runConvErr(t, a, "prefix is not addr", a.Addr, a.MustAddr)
+
+	a = Value("[2001:db8::1]:80")
+	runConvOk(t, a, "addrport", netip.MustParseAddrPort("[2001:db8::1]:80"), a.AddrPort, a.MustAddrPort)
+
+	for _, in := range []string{"", "example.com", "256.0.0.1", "1.2.3"} {
+		a := Value(in)
+		runConvErr(t, a, "bad addr "+in, a.Addr, a.MustAddr)
+	}
+
+	for _, in := range []string{"10.0.0.0", "10.0.0.0/33", "/8"} {
+		a := Value(in)
+		runConvErr(t, a, "bad prefix "+in, a.Prefix, a.MustPrefix)
+	}
+
+	for _, in := range []string{"192.0.2.1", "2001:db8::1:80", "example.com:80", "192.0.2.1:65536"} {
+		a := Value(in)
+		runConvErr(t, a, "bad addrport "+in, a.AddrPort, a.MustAddrPort)
+	}
+
+	_, err := Value("nope").Prefix()
+	var perr *ParseError
+	if !errors.As(err, &perr) || !strings.Contains(err.Error(), "want an IP prefix, like 10.0.0.0/8") {
+		t.Errorf("error should name the expected format, got %v", err)
+	}
+}
+
+func TestHostPort(t *testing.T) {
+	good := map[string]HostPort{
+		"example.com":        {"example.com", 443},
+		"example.com:8443":   {"example.com", 8443},
+		"example.com.:80":    {"example.com.", 80},
+		"localhost":          {"localhost", 443},
+		"192.0.2.1:80":       {"192.0.2.1", 80},
+		"[2001:db8::1]:80":   {"2001:db8::1", 80},
+		"[2001:db8::1]":      {"2001:db8::1", 443},
+		"2001:db8::1":        {"2001:db8::1", 443},
+		":8080":              {"", 8080},
+		"my-host.internal:0": {"my-host.internal", 0},
+	}
+
+	for in, expect := range good {
+		t.Run(in, func(t *testing.T) {
+			a := Value(in)
+			got, err := a.HostPort(443)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got != expect {
+				t.Errorf("want %+v, got %+v", expect, got)
+			}
+
+			if must := a.MustHostPort(443); must != expect {
+				t.Errorf("want %+v, got %+v", expect, must)
+			}
+		})
+	}
+
+	bad := []string{
+		"", "example.com:", "example.com:http", "example.com:65536", "-bad.com",
+		"exa_mple.com", "[192.0.2.1]:80", "[2001:db8::1", "[2001:db8::1]80",
+		"2001:db8::1:80:", "1.2.3", "a..b",
+	}
+
+	for _, in := range bad {
+		t.Run("bad "+in, func(t *testing.T) {
+			_, err := Value(in).HostPort(443)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected ParseError, got %v", err)
+			}
+		})
+	}
+
+	_, err := Value("example.com").HostPort(0)
+	if err == nil || !strings.Contains(err.Error(), "missing port") {
+		t.Errorf("port should be required without a default, got %v", err)
+	}
+
+	hp := HostPort{"2001:db8::1", 80}
+	if s := hp.String(); s != "[2001:db8::1]:80" {
+		t.Errorf("bad String: %s", s)
+	}
+
+	if back := Value(hp.String()).MustHostPort(0); back != hp {
+		t.Errorf("HostPort doesn't round-trip: got %+v", back)
+	}
+}
+
+func TestURL(t *testing.T) {
+	u, err := Value("https://example.com/path?q=1").URL()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if u.Scheme != "https" || u.Host != "example.com" || u.Path != "/path" || u.RawQuery != "q=1" {
+		t.Errorf("bad URL: %#v", u)
+	}
+
+	if u := Value("HTTP://example.com").MustURL("http", "https"); u.Scheme != "http" {
+		t.Errorf("scheme should match case-insensitively, got %s", u.Scheme)
+	}
+
+	if u := Value("mailto:user@example.com").MustURL(); u.Opaque != "user@example.com" {
+		t.Errorf("opaque URL: got %#v", u)
+	}
+
+	for _, in := range []string{"", "example.com/path", "/just/a/path", "https://", "http://[::1"} {
+		a := Value(in)
+		t.Run("bad "+in, func(t *testing.T) {
+			_, err := a.URL()
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected ParseError, got %v", err)
+			}
+		})
+	}
+
+	_, err = Value("ftp://example.com").URL("http", "https")
+	if err == nil || !strings.Contains(err.Error(), "want a URL with scheme http or https") {
+		t.Errorf("error should name the allowed schemes, got %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package lexopt
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Path converts Arg to an absolute, cleaned file path, expanding a leading ~
+// to the user's home directory. See [PathResolver.Path] for details, and to
+// control the working directory or expand environment variables.
+func (a Arg) Path() (string, error) { return PathResolver{}.Path(a) }
+
+// MustPath is like [Arg.Path], but panics if the conversion fails.
+func (a Arg) MustPath() string { return must(a.Path()) }
+
+// ExistingFile is like [Arg.Path], but the path must name an existing file
+// that is not a directory.
+func (a Arg) ExistingFile() (string, error) { return PathResolver{}.ExistingFile(a) }
+
+// MustExistingFile is like [Arg.ExistingFile], but panics if the conversion
+// fails.
+func (a Arg) MustExistingFile() string { return must(a.ExistingFile()) }
+
+// ExistingDir is like [Arg.Path], but the path must name an existing
+// directory.
+func (a Arg) ExistingDir() (string, error) { return PathResolver{}.ExistingDir(a) }
+
+// MustExistingDir is like [Arg.ExistingDir], but panics if the conversion
+// fails.
+func (a Arg) MustExistingDir() string { return must(a.ExistingDir()) }
+
+// OpenInput opens the file named by the argument for reading, or returns
+// standard input if the argument is "-". Closing standard input this way
+// does nothing.
+func (a Arg) OpenInput() (io.ReadCloser, error) { return PathResolver{}.OpenInput(a) }
+
+// CreateOutput creates or truncates the file named by the argument, or
+// returns standard output if the argument is "-". Closing standard output
+// this way does nothing.
+func (a Arg) CreateOutput() (io.WriteCloser, error) { return PathResolver{}.CreateOutput(a) }
+
+// PathResolver turns path arguments into paths and files. The zero value
+// works on the real file system, in the process's working directory; the
+// fields replace parts of the environment, mostly so that tests can use a
+// [testing/fstest.MapFS]:
+//
+//	r := lexopt.PathResolver{
+//		FS:   fstest.MapFS{"home/me/notes.txt": {Data: []byte("hi")}},
+//		Dir:  "/home/me",
+//		Home: "/home/me",
+//	}
+//	path, err := r.ExistingFile(lexopt.Value("~/notes.txt")) // "/home/me/notes.txt"
+type PathResolver struct {
+	// FS is the file system that absolute paths are looked up in, with the
+	// leading slash (and any volume name, like C:) removed and slashes for
+	// separators, as fs.FS requires. If nil, paths are looked up as they are
+	// in the real file system, with [os.Stat] and [os.Open].
+	FS fs.FS
+
+	// Dir is the working directory that relative paths are resolved
+	// against. If empty, it is [os.Getwd].
+	Dir string
+
+	// Home is what ~ expands to. If empty, it is [os.UserHomeDir].
+	Home string
+
+	// ExpandEnv expands $VAR and ${VAR} in paths, as [os.Expand] does.
+	ExpandEnv bool
+
+	// Getenv looks up environment variables for ExpandEnv. If nil, it is
+	// [os.Getenv].
+	Getenv func(string) string
+
+	// Stdin and Stdout are what "-" stands for in OpenInput and
+	// CreateOutput. If nil, they are [os.Stdin] and [os.Stdout].
+	Stdin  io.Reader
+	Stdout io.Writer
+
+	// Create creates output files for CreateOutput, since an fs.FS can't. If
+	// nil, it is [os.Create].
+	Create func(name string) (io.WriteCloser, error)
+}
+
+// Path converts a to an absolute, cleaned file path. A ~ on its own or
+// followed by a slash is replaced with the home directory; ~user is left as
+// it is. If ExpandEnv is set, environment variables are expanded first, so a
+// variable can hold a path that starts with ~. Relative paths are taken
+// relative to the working directory. Path does not check that the file
+// exists, and does not treat "-" specially.
+func (r PathResolver) Path(a Arg) (string, error) {
+	fail := func(err error) (string, error) {
+		return "", &ParseError{Value: a.s, Want: "a path", Err: err}
+	}
+
+	path := a.s
+	if r.ExpandEnv {
+		getenv := r.Getenv
+		if getenv == nil {
+			getenv = os.Getenv
+		}
+		path = os.Expand(path, getenv)
+	}
+
+	if path == "" {
+		return fail(nil)
+	}
+
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home := r.Home
+		if home == "" {
+			var err error
+			if home, err = os.UserHomeDir(); err != nil {
+				return fail(err)
+			}
+		}
+		path = home + path[1:]
+	}
+
+	if !filepath.IsAbs(path) {
+		dir := r.Dir
+		if dir == "" {
+			var err error
+			if dir, err = os.Getwd(); err != nil {
+				return fail(err)
+			}
+		}
+		path = filepath.Join(dir, path)
+	}
+
+	return filepath.Clean(path), nil
+}
+
+// ExistingFile is like [PathResolver.Path], but the path must name an
+// existing file that is not a directory. If it doesn't exist, the error
+// wraps [fs.ErrNotExist].
+func (r PathResolver) ExistingFile(a Arg) (string, error) {
+	return r.existing(a, "an existing file", false)
+}
+
+// ExistingDir is like [PathResolver.Path], but the path must name an
+// existing directory. If it doesn't exist, the error wraps
+// [fs.ErrNotExist].
+func (r PathResolver) ExistingDir(a Arg) (string, error) {
+	return r.existing(a, "an existing directory", true)
+}
+
+// existing does the work for ExistingFile and ExistingDir.
+func (r PathResolver) existing(a Arg, want string, wantDir bool) (string, error) {
+	path, err := r.Path(a)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := r.stat(path)
+	if err != nil {
+		return "", &ParseError{Value: a.s, Want: want, Err: err}
+	}
+
+	switch {
+	case wantDir && !info.IsDir():
+		return "", &ParseError{Value: a.s, Want: want, Err: errors.New("not a directory")}
+	case !wantDir && info.IsDir():
+		return "", &ParseError{Value: a.s, Want: want, Err: errors.New("is a directory")}
+	}
+
+	return path, nil
+}
+
+// OpenInput opens the file named by a for reading, or returns Stdin if a is
+// "-". Closing Stdin this way does nothing.
+func (r PathResolver) OpenInput(a Arg) (io.ReadCloser, error) {
+	if a.s == "-" {
+		if r.Stdin == nil {
+			return io.NopCloser(os.Stdin), nil
+		}
+		return io.NopCloser(r.Stdin), nil
+	}
+
+	path, err := r.Path(a)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := r.open(path)
+	if err != nil {
+		return nil, &ParseError{Value: a.s, Want: "a readable file", Err: err}
+	}
+
+	return f, nil
+}
+
+// CreateOutput creates or truncates the file named by a with Create, or
+// returns Stdout if a is "-". Closing Stdout this way does nothing.
+func (r PathResolver) CreateOutput(a Arg) (io.WriteCloser, error) {
+	if a.s == "-" {
+		if r.Stdout == nil {
+			return nopWriteCloser{os.Stdout}, nil
+		}
+		return nopWriteCloser{r.Stdout}, nil
+	}
+
+	path, err := r.Path(a)
+	if err != nil {
+		return nil, err
+	}
+
+	create := r.Create
+	if create == nil {
+		create = func(name string) (io.WriteCloser, error) { return os.Create(name) }
+	}
+
+	w, err := create(path)
+	if err != nil {
+		return nil, &ParseError{Value: a.s, Want: "a writable file", Err: err}
+	}
+
+	return w, nil
+}
+
+// stat returns information about the file at the absolute path, in FS if it
+// is set. Otherwise the native path is used, since an fs.FS can't name
+// Windows paths like C:\x.
+func (r PathResolver) stat(path string) (fs.FileInfo, error) {
+	if r.FS == nil {
+		return os.Stat(path)
+	}
+	return fs.Stat(r.FS, fsPath(path))
+}
+
+// open opens the file at the absolute path, in FS if it is set, as for stat.
+func (r PathResolver) open(path string) (fs.File, error) {
+	if r.FS == nil {
+		return os.Open(path)
+	}
+	return r.FS.Open(fsPath(path))
+}
+
+// fsPath turns an absolute path into the unrooted, slash-separated form that
+// fs.FS uses.
+func fsPath(path string) string {
+	path = strings.TrimPrefix(path, filepath.VolumeName(path))
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+// nopWriteCloser is a Writer whose Close does nothing, like io.NopCloser.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
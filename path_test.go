@@ -0,0 +1,232 @@
+package lexopt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testResolver() PathResolver {
+	return PathResolver{
+		FS: fstest.MapFS{
+			"home/me/notes.txt":     {Data: []byte("notes")},
+			"home/me/src/main.go":   {Data: []byte("package main")},
+			"etc/app.conf":          {Data: []byte("conf")},
+			"home/me/My Files/a.md": {Data: []byte("a")},
+		},
+		Dir:    "/home/me/src",
+		Home:   "/home/me",
+		Getenv: func(name string) string { return map[string]string{"CONF": "/etc", "TILDE": "~/notes.txt"}[name] },
+	}
+}
+
+func TestPath(t *testing.T) {
+	r := testResolver()
+
+	tests := map[string]string{
+		"main.go":          "/home/me/src/main.go",
+		"../notes.txt":     "/home/me/notes.txt",
+		"./a/../b//c/":     "/home/me/src/b/c",
+		"/etc/app.conf":    "/etc/app.conf",
+		"~":                "/home/me",
+		"~/notes.txt":      "/home/me/notes.txt",
+		"~other/x":         "/home/me/src/~other/x",
+		"$CONF/app.conf":   "/home/me/src/$CONF/app.conf",
+		"-":                "/home/me/src/-",
+		"My Files/../x.md": "/home/me/src/x.md",
+	}
+
+	for in, expect := range tests {
+		if got, err := r.Path(Value(in)); err != nil || got != expect {
+			t.Errorf("%s: want %s, got %q, %v", in, expect, got, err)
+		}
+	}
+
+	r.ExpandEnv = true
+	expanded := map[string]string{
+		"$CONF/app.conf":   "/etc/app.conf",
+		"${CONF}/app.conf": "/etc/app.conf",
+		"$TILDE":           "/home/me/notes.txt",
+		"$UNSET/x":         "/x",
+	}
+
+	for in, expect := range expanded {
+		if got, err := r.Path(Value(in)); err != nil || got != expect {
+			t.Errorf("expand %s: want %s, got %q, %v", in, expect, got, err)
+		}
+	}
+
+	for _, in := range []string{"", "$UNSET"} {
+		_, err := r.Path(Value(in))
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: expected ParseError, got %v", in, err)
+		}
+	}
+
+	// The zero resolver uses the process's working directory.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Value("x/y").MustPath(); got != filepath.Join(wd, "x/y") {
+		t.Errorf("relative to working directory: got %s", got)
+	}
+}
+
+func TestExistingFile(t *testing.T) {
+	r := testResolver()
+
+	if got, err := r.ExistingFile(Value("~/notes.txt")); err != nil || got != "/home/me/notes.txt" {
+		t.Errorf("want /home/me/notes.txt, got %q, %v", got, err)
+	}
+
+	if got, err := r.ExistingDir(Value("..")); err != nil || got != "/home/me" {
+		t.Errorf("want /home/me, got %q, %v", got, err)
+	}
+
+	if got, err := r.ExistingDir(Value("/")); err != nil || got != "/" {
+		t.Errorf("want /, got %q, %v", got, err)
+	}
+
+	_, err := r.ExistingFile(Value("missing.go"))
+	var perr *ParseError
+	if !errors.As(err, &perr) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file: expected ParseError wrapping fs.ErrNotExist, got %v", err)
+	}
+
+	if _, err := r.ExistingFile(Value("/etc")); err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Errorf("directory as file: got %v", err)
+	}
+
+	if _, err := r.ExistingDir(Value("main.go")); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("file as directory: got %v", err)
+	}
+
+	if _, err := r.ExistingDir(Value("/nowhere")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing directory: got %v", err)
+	}
+
+	if got := Value(".").MustExistingDir(); got == "" {
+		t.Errorf("working directory should exist")
+	}
+
+	// The zero resolver uses native paths.
+	dir := t.TempDir()
+	file := filepath.Join(dir, "real.txt")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := Value(file).ExistingFile(); err != nil || got != file {
+		t.Errorf("want %s, got %q, %v", file, got, err)
+	}
+
+	if got, err := Value(dir).ExistingDir(); err != nil || got != dir {
+		t.Errorf("want %s, got %q, %v", dir, got, err)
+	}
+}
+
+func TestOpenInput(t *testing.T) {
+	r := testResolver()
+	r.Stdin = strings.NewReader("from stdin")
+
+	for in, expect := range map[string]string{"-": "from stdin", "~/notes.txt": "notes", "/etc/app.conf": "conf"} {
+		f, err := r.OpenInput(Value(in))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", in, err)
+			continue
+		}
+
+		data, err := io.ReadAll(f)
+		if err != nil || string(data) != expect {
+			t.Errorf("%s: want %q, got %q, %v", in, expect, data, err)
+		}
+
+		if err := f.Close(); err != nil {
+			t.Errorf("%s: close: %s", in, err)
+		}
+	}
+
+	if _, err := r.OpenInput(Value("missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file: got %v", err)
+	}
+}
+
+type bufferCloser struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *bufferCloser) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestCreateOutput(t *testing.T) {
+	var stdout bytes.Buffer
+	files := map[string]*bufferCloser{}
+
+	r := testResolver()
+	r.Stdout = &stdout
+	r.Create = func(name string) (io.WriteCloser, error) {
+		if strings.HasPrefix(name, "/etc/") {
+			return nil, fs.ErrPermission
+		}
+
+		files[name] = &bufferCloser{}
+		return files[name], nil
+	}
+
+	w, err := r.CreateOutput(Value("-"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	io.WriteString(w, "to stdout")
+	w.Close()
+
+	if stdout.String() != "to stdout" {
+		t.Errorf("want output on stdout, got %q", stdout.String())
+	}
+
+	w, err = r.CreateOutput(Value("~/out.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	io.WriteString(w, "to file")
+	w.Close()
+
+	if f := files["/home/me/out.txt"]; f == nil || f.String() != "to file" || !f.closed {
+		t.Errorf("file not written as expected: %+v", files)
+	}
+
+	if _, err := r.CreateOutput(Value("/etc/x")); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("expected permission error, got %v", err)
+	}
+
+	// The zero resolver writes real files.
+	path := filepath.Join(t.TempDir(), "real.txt")
+	w, err = Value(path).CreateOutput()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	io.WriteString(w, "real")
+	w.Close()
+
+	f, err := Value(path).OpenInput()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer f.Close()
+
+	if data, _ := io.ReadAll(f); string(data) != "real" {
+		t.Errorf("round trip through the real file system: got %q", data)
+	}
+}
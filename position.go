@@ -0,0 +1,53 @@
+package lexopt
+
+import "fmt"
+
+// Position describes where on the command line an [Arg] came from. Arg values
+// themselves carry no position, so that they can be compared with ==; use
+// [Parser.Position], [Parser.CurrentPosition], [Parser.ValuesPositions] and
+// [RawArgs.Position] to find out where an Arg came from.
+type Position struct {
+	Index int    // index of Token in the arguments, not counting the binary name
+	Start int    // byte offset in Token where the arg starts
+	End   int    // byte offset in Token where the arg ends
+	Token string // the original token, as given on the command line
+}
+
+// wholeToken returns a Position covering the whole of argv[idx].
+func wholeToken(argv []string, idx int) Position {
+	return Position{Index: idx, Start: 0, End: len(argv[idx]), Token: argv[idx]}
+}
+
+// Text returns the part of Token that the Position covers. For options, this
+// includes the leading dashes, except for short options after the first in a
+// cluster: for -abc, the text is "-a", "b", and "c", respectively.
+func (pos Position) Text() string {
+	return pos.Token[pos.Start:pos.End]
+}
+
+// String returns a description of the position suitable for error messages,
+// like `"b" in "-abc" (argument 3)`. Arguments are counted from 1.
+func (pos Position) String() string {
+	if pos.Start == 0 && pos.End == len(pos.Token) {
+		return fmt.Sprintf("%q (argument %d)", pos.Token, pos.Index+1)
+	}
+
+	return fmt.Sprintf("%q in %q (argument %d)", pos.Text(), pos.Token, pos.Index+1)
+}
+
+// ArgError is an error that happened at a particular place on the command
+// line. The parser returns its own errors wrapped in an ArgError, and
+// [Parser.WrapErr] wraps others. Use errors.Is and errors.As to get at the
+// underlying error.
+type ArgError struct {
+	Pos Position
+	Err error
+}
+
+func (e *ArgError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Err)
+}
+
+func (e *ArgError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,381 @@
+package lexopt
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// redacted is what a Secret shows instead of its value.
+const redacted = "[REDACTED]"
+
+// Secret is a value, like a password or token, that should not be printed or
+// logged. Its String and DashedString methods, its formatting with any fmt
+// verb, its text marshaling and its slog value all show [REDACTED] instead of
+// the value; use Reveal to get at it.
+type Secret struct {
+	value string
+}
+
+// Reveal returns the secret value.
+func (s Secret) Reveal() string { return s.value }
+
+// String returns "[REDACTED]".
+func (s Secret) String() string { return redacted }
+
+// DashedString returns "[REDACTED]", so that a Secret can stand in for the
+// Arg it came from when printing.
+func (s Secret) DashedString() string { return redacted }
+
+// Format implements [fmt.Formatter], so that the value is redacted whatever
+// the verb, including %#v, %x and %q.
+func (s Secret) Format(f fmt.State, verb rune) { io.WriteString(f, redacted) }
+
+// MarshalText implements [encoding.TextMarshaler], so that the value is
+// redacted when a Secret is written as JSON and the like.
+func (s Secret) MarshalText() ([]byte, error) { return []byte(redacted), nil }
+
+// LogValue implements [slog.LogValuer], so that the value is redacted in
+// structured logs.
+func (s Secret) LogValue() slog.Value { return slog.StringValue(redacted) }
+
+// InlineSecretError is returned by [SecretSource.Value] when an option whose
+// [SecretPolicy] is SecretRequireIndirect is given its value directly. The
+// error does not include the value.
+type InlineSecretError struct {
+	Option string // the option, as typed
+}
+
+func (e *InlineSecretError) Error() string {
+	return fmt.Sprintf("%s must not be given directly; use @file, env:VAR or fd:N", e.Option)
+}
+
+// SecretPolicy says how an option in [Parser.Secrets] may be given its value.
+type SecretPolicy int
+
+const (
+	// SecretAllowInline accepts the value itself, as in --token abc, as well
+	// as indirect values. The value is still redacted in the parser's output.
+	SecretAllowInline SecretPolicy = iota
+
+	// SecretRequireIndirect accepts only @file, env:VAR and fd:N, so that
+	// the secret doesn't show up in the process list or shell history.
+	SecretRequireIndirect
+)
+
+// SecretSource resolves the value of an argument into a [Secret]. A value of
+// the form @file is read from the named file (@- is standard input), env:VAR
+// from the environment variable VAR, and fd:N from the open file descriptor
+// N; a single trailing newline is removed from what's read. Anything else is
+// the secret itself. The zero value uses the real environment.
+type SecretSource struct {
+	// Paths opens @file values; see [PathResolver.OpenInput].
+	Paths PathResolver
+
+	// LookupEnv looks up env:VAR values. If nil, it is [os.LookupEnv].
+	LookupEnv func(string) (string, bool)
+
+	// OpenFD opens fd:N values. If nil, it uses [os.NewFile].
+	OpenFD func(fd uintptr) (io.ReadCloser, error)
+}
+
+// Secret resolves the argument into a [Secret], using the zero
+// [SecretSource].
+func (a Arg) Secret() (Secret, error) { return SecretSource{}.Resolve(a) }
+
+// MustSecret is like [Arg.Secret], but panics if the conversion fails.
+func (a Arg) MustSecret() Secret { return must(a.Secret()) }
+
+// SecretValue is like [Parser.Value], but resolves the value into a [Secret]
+// with the zero [SecretSource], and applies the policy in [Parser.Secrets].
+func (p *Parser) SecretValue() (Secret, error) { return SecretSource{}.Value(p) }
+
+// Value takes a value for the parser's Current option, as [Parser.Value]
+// does, and resolves it into a [Secret]. If the option's policy in
+// [Parser.Secrets] is SecretRequireIndirect and the value is not @file,
+// env:VAR or fd:N, Value returns an error wrapping an [InlineSecretError].
+// Errors never include the secret.
+func (s SecretSource) Value(p *Parser) (Secret, error) {
+	val, err := p.Value()
+	if err != nil {
+		return Secret{}, err
+	}
+
+	if p.Secrets[p.Current] == SecretRequireIndirect && !isIndirect(val.s) {
+		return Secret{}, p.currentErr(&InlineSecretError{Option: p.DashedCurrent()})
+	}
+
+	secret, err := s.Resolve(val)
+	if err != nil {
+		return Secret{}, p.WrapErr(err)
+	}
+
+	return secret, nil
+}
+
+// Resolve resolves a into a [Secret], as described for [SecretSource]. It
+// applies no policy.
+func (s SecretSource) Resolve(a Arg) (Secret, error) {
+	switch {
+	case strings.HasPrefix(a.s, "@"):
+		f, err := s.Paths.OpenInput(Value(a.s[1:]))
+		if err != nil {
+			return Secret{}, err
+		}
+		defer f.Close()
+		return readSecret(f, a.s)
+
+	case strings.HasPrefix(a.s, "env:"):
+		lookup := s.LookupEnv
+		if lookup == nil {
+			lookup = os.LookupEnv
+		}
+
+		val, ok := lookup(a.s[len("env:"):])
+		if !ok {
+			return Secret{}, &ParseError{Value: a.s, Want: "a variable that is set"}
+		}
+		return Secret{val}, nil
+
+	case strings.HasPrefix(a.s, "fd:"):
+		n, err := strconv.ParseUint(a.s[len("fd:"):], 10, 31)
+		if err != nil {
+			return Secret{}, &ParseError{Value: a.s, Want: "a file descriptor number, like fd:3", Err: err}
+		}
+
+		open := s.OpenFD
+		if open == nil {
+			open = openFD
+		}
+
+		f, err := open(uintptr(n))
+		if err != nil {
+			return Secret{}, &ParseError{Value: a.s, Want: "an open file descriptor", Err: err}
+		}
+		defer f.Close()
+		return readSecret(f, a.s)
+	}
+
+	return Secret{a.s}, nil
+}
+
+// MustResolve is like [SecretSource.Resolve], but panics if the conversion
+// fails.
+func (s SecretSource) MustResolve(a Arg) Secret { return must(s.Resolve(a)) }
+
+// isIndirect reports whether val names where a secret is, rather than being
+// the secret itself.
+func isIndirect(val string) bool {
+	return strings.HasPrefix(val, "@") || strings.HasPrefix(val, "env:") || strings.HasPrefix(val, "fd:")
+}
+
+// openFD opens file descriptor fd for reading.
+func openFD(fd uintptr) (io.ReadCloser, error) {
+	f := os.NewFile(fd, "fd:"+strconv.FormatUint(uint64(fd), 10))
+	if f == nil {
+		return nil, os.ErrInvalid
+	}
+	return f, nil
+}
+
+// readSecret reads a secret from r, dropping one trailing newline. The
+// source is the argument it came from, for errors.
+func readSecret(r io.Reader, source string) (Secret, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Secret{}, &ParseError{Value: source, Want: "a readable secret", Err: err}
+	}
+
+	val := strings.TrimSuffix(string(data), "\n")
+	val = strings.TrimSuffix(val, "\r")
+	return Secret{val}, nil
+}
+
+// redactPos returns pos as it appears in argv, the result of p.redactArgv():
+// if redactArgv hid part of its token, offsets in that part are moved to the
+// [REDACTED] that replaced it.
+func (p *Parser) redactPos(argv []string, pos Position) Position {
+	if !p.hidden(argv, pos) {
+		return pos
+	}
+
+	tok := argv[pos.Index]
+	keep := len(tok) - len(redacted)
+	if pos.Start > keep {
+		pos.Start = keep
+	}
+	if pos.End > keep {
+		pos.End = len(tok)
+	}
+	pos.Token = tok
+
+	return pos
+}
+
+// redactErr returns the message of err with secrets redacted, for the
+// parser's debugging output. Positions of ArgErrors are shown as they are in
+// argv, the result of p.redactArgv(), and quoted secrets in the rest of the
+// message, as in strconv.ParseInt: parsing "hunter2", are hidden too, along
+// with what fixConfusable would make of them.
+func (p *Parser) redactErr(argv []string, err error) string {
+	msg := err.Error()
+	if len(p.Secrets) == 0 {
+		return msg
+	}
+
+	for _, argErr := range argErrors(err) {
+		if p.hidden(argv, argErr.Pos) {
+			msg = strings.ReplaceAll(msg, argErr.Pos.String(), p.redactPos(argv, argErr.Pos).String())
+		}
+	}
+
+	for i, tok := range argv {
+		if tok == p.argv[i] {
+			continue
+		}
+
+		secret := p.argv[i][len(tok)-len(redacted):]
+		if secret == "" {
+			continue
+		}
+
+		msg = strings.ReplaceAll(msg, strconv.Quote(secret), strconv.Quote(redacted))
+		if fixed, ok := fixConfusable(secret); ok {
+			// As in a ConfusableError's suggestion
+			msg = strings.ReplaceAll(msg, strconv.Quote(fixed), strconv.Quote(redacted))
+		}
+	}
+
+	return msg
+}
+
+// argErrors returns the ArgErrors in err's tree.
+func argErrors(err error) []*ArgError {
+	switch e := err.(type) {
+	case *ArgError:
+		return []*ArgError{e}
+
+	case interface{ Unwrap() []error }:
+		var errs []*ArgError
+		for _, err := range e.Unwrap() {
+			errs = append(errs, argErrors(err)...)
+		}
+		return errs
+
+	case interface{ Unwrap() error }:
+		return argErrors(e.Unwrap())
+
+	default:
+		return nil
+	}
+}
+
+// redactArgv returns argv with the values of the options in p.Secrets
+// replaced by [REDACTED], for debugging output. Each token is read as Next
+// reads it, with confusables fixed and plus and number options recognised,
+// but since lexopt doesn't know which options take values, any value that a
+// secret option could take is hidden.
+func (p *Parser) redactArgv() []string {
+	if len(p.Secrets) == 0 {
+		return p.argv
+	}
+
+	out := make([]string, len(p.argv))
+	copy(out, p.argv)
+
+	for i := 0; i < len(out); i++ {
+		tok := out[i]
+		fixed, _ := p.fixConfusable(tok)
+		if fixed == "--" {
+			return out
+		}
+
+		start, attached := p.secretValue(fixed)
+		switch {
+		case start < 0:
+			continue
+
+		case attached:
+			// fixConfusable only changes the front of a token, so the value
+			// is as far from the end of tok as from the end of fixed.
+			out[i] = tok[:len(tok)-len(fixed)+start] + redacted
+
+		case i+1 < len(out):
+			i++
+			out[i] = redacted
+		}
+	}
+
+	return out
+}
+
+// secretValue returns the offset in tok, a token with its confusables fixed,
+// of the value of the first option in p.Secrets that it holds, and whether
+// the value is in tok, as in --name=value or -nvalue, rather than being the
+// next argument. It returns -1 if tok holds no secret option.
+func (p *Parser) secretValue(tok string) (int, bool) {
+	switch {
+	case strings.HasPrefix(tok, "--"):
+		return p.secretLong(argLong, tok)
+
+	case strings.HasPrefix(tok, "-") && tok != "-":
+		digits := 0
+		if p.NumberOptions {
+			digits = countDigits(tok[1:])
+		}
+		return p.secretShort(argShort, tok, 1+digits)
+
+	case p.PlusOptions && isPlusOption(tok):
+		if strings.HasPrefix(tok, "++") {
+			return p.secretLong(argPlusLong, tok)
+		}
+		return p.secretShort(argPlus, tok, 1)
+
+	default:
+		return -1, false
+	}
+}
+
+// secretLong is secretValue for a long option of the given kind.
+func (p *Parser) secretLong(kind argType, tok string) (int, bool) {
+	typed, _, hasValue := strings.Cut(tok[2:], "=")
+	name := typed
+	if p.NormalizeLong != nil {
+		name = p.NormalizeLong(name)
+	}
+
+	if _, ok := p.Secrets[Arg{kind, name}]; !ok {
+		return -1, false
+	}
+
+	if hasValue {
+		return 2 + len(typed) + 1, true
+	}
+	return len(tok), false
+}
+
+// secretShort is secretValue for a cluster of short options of the given
+// kind, starting at tok[from].
+func (p *Parser) secretShort(kind argType, tok string, from int) (int, bool) {
+	for i, r := range tok[from:] {
+		if _, ok := p.Secrets[Arg{kind, string(r)}]; !ok {
+			continue
+		}
+
+		start := from + i + utf8.RuneLen(r)
+		if start == len(tok) {
+			return start, false
+		}
+
+		if tok[start] == '=' {
+			start++
+		}
+		return start, true
+	}
+
+	return -1, false
+}
@@ -0,0 +1,328 @@
+package lexopt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testSecretSource() SecretSource {
+	return SecretSource{
+		Paths: PathResolver{
+			FS: fstest.MapFS{
+				"run/secrets/token": {Data: []byte("file-token\n")},
+				"run/secrets/crlf":  {Data: []byte("crlf-token\r\n")},
+				"run/secrets/two":   {Data: []byte("line\n\n")},
+			},
+			Dir:   "/run/secrets",
+			Stdin: strings.NewReader("stdin-token\n"),
+		},
+		LookupEnv: func(name string) (string, bool) {
+			val, ok := map[string]string{"TOKEN": "env-token", "EMPTY": ""}[name]
+			return val, ok
+		},
+		OpenFD: func(fd uintptr) (io.ReadCloser, error) {
+			if fd != 3 {
+				return nil, fs.ErrInvalid
+			}
+			return io.NopCloser(strings.NewReader("fd-token")), nil
+		},
+	}
+}
+
+func TestSecretRedaction(t *testing.T) {
+	s := Value("hunter2").MustSecret()
+	if s.Reveal() != "hunter2" {
+		t.Fatalf("Reveal: got %q", s.Reveal())
+	}
+
+	type config struct {
+		User     string
+		Password Secret
+	}
+	c := config{"me", s}
+
+	outputs := []string{
+		s.String(),
+		s.DashedString(),
+		fmt.Sprint(s),
+		fmt.Sprintf("%v %+v %#v %s %q %x %d", s, c, c, s, s, s, s),
+		fmt.Sprintf("%v", &c),
+	}
+
+	j, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs = append(outputs, string(j))
+
+	var log bytes.Buffer
+	slog.New(slog.NewTextHandler(&log, nil)).Info("login", "password", s)
+	outputs = append(outputs, log.String())
+
+	for _, out := range outputs {
+		if strings.Contains(out, "hunter2") || !strings.Contains(out, "[REDACTED]") {
+			t.Errorf("secret not redacted: %s", out)
+		}
+	}
+}
+
+func TestSecretSource(t *testing.T) {
+	src := testSecretSource()
+
+	good := map[string]string{
+		"literal":            "literal",
+		"@token":             "file-token",
+		"@/run/secrets/crlf": "crlf-token",
+		"@two":               "line\n",
+		"@-":                 "stdin-token",
+		"env:TOKEN":          "env-token",
+		"env:EMPTY":          "",
+		"fd:3":               "fd-token",
+		"":                   "",
+	}
+
+	for in, expect := range good {
+		got, err := src.Resolve(Value(in))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", in, err)
+			continue
+		}
+
+		if got.Reveal() != expect {
+			t.Errorf("%s: want %q, got %q", in, expect, got.Reveal())
+		}
+	}
+
+	for _, in := range []string{"@missing", "env:UNSET", "fd:4", "fd:x", "fd:-1", "@"} {
+		_, err := src.Resolve(Value(in))
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%s: expected ParseError, got %v", in, err)
+		}
+	}
+
+	if _, err := src.Resolve(Value("@missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file should wrap fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestSecretPolicy(t *testing.T) {
+	p := NewFromArgs([]string{"--token", "env:TOKEN", "--password=hunter2", "-k", "hunter3", "--token=@token"})
+	p.Secrets = map[Arg]SecretPolicy{
+		Long("token"):    SecretRequireIndirect,
+		Long("password"): SecretAllowInline,
+		Short('k'):       SecretRequireIndirect,
+	}
+
+	src := testSecretSource()
+	expect := []string{"env-token", "hunter2", "", "file-token"}
+
+	for _, want := range expect {
+		if !p.Next() {
+			t.Fatalf("Next: %v", p.Err())
+		}
+
+		s, err := src.Value(p)
+		if want == "" {
+			var ierr *InlineSecretError
+			if !errors.As(err, &ierr) || ierr.Option != "-k" {
+				t.Fatalf("expected InlineSecretError for -k, got %v", err)
+			}
+
+			if strings.Contains(err.Error(), "hunter3") {
+				t.Errorf("error leaks the secret: %s", err)
+			}
+
+			var argErr *ArgError
+			if !errors.As(err, &argErr) || argErr.Pos.Index != 3 || argErr.Pos.Token != "-k" {
+				t.Errorf("want an ArgError at -k, got %v", err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if s.Reveal() != want {
+			t.Errorf("want %q, got %q", want, s.Reveal())
+		}
+	}
+
+	if p.Next() {
+		t.Errorf("expected end of arguments, got %s", p.Current)
+	}
+
+	// Options without a policy take anything, through the zero SecretSource.
+	p = NewFromArgs([]string{"--key", "abc"})
+	p.Next()
+	if s, err := p.SecretValue(); err != nil || s.Reveal() != "abc" {
+		t.Errorf("want abc, got %q, %v", s.Reveal(), err)
+	}
+
+	p = NewFromArgs([]string{"--key"})
+	p.Next()
+	if _, err := p.SecretValue(); !errors.Is(err, ErrNoValue) {
+		t.Errorf("expected ErrNoValue, got %v", err)
+	}
+}
+
+func TestSecretDumpState(t *testing.T) {
+	p := NewFromArgs([]string{"-v", "--token", "s3cret1", "--token=s3cret2", "-ks3cret3", "-vk", "s3cret4", "-k=s3cret5", "--Token", "s3cret6", "--", "--token", "visible"})
+	p.NormalizeLong = FoldCase
+	p.Secrets = map[Arg]SecretPolicy{Long("token"): SecretAllowInline, Short('k'): SecretAllowInline}
+
+	dump := func() string {
+		var w strings.Builder
+		p.dumpState(&w)
+		return w.String()
+	}
+
+	p.Next()
+	p.Next()
+	if out := dump(); strings.Contains(out, "s3cret") {
+		t.Errorf("dump leaks a secret:\n%s", out)
+	}
+
+	p.Next() // the value of the first --token, as a positional
+	if out := dump(); strings.Contains(out, "s3cret") {
+		t.Errorf("dump leaks a secret:\n%s", out)
+	}
+
+	p.Next() // --token=s3cret2, with its value pending
+	if out := dump(); strings.Contains(out, "s3cret") {
+		t.Errorf("dump leaks a secret:\n%s", out)
+	}
+
+	p.Value()
+	p.Next() // -k, from -ks3cret3
+	if out := dump(); strings.Contains(out, "s3cret") {
+		t.Errorf("dump leaks a secret:\n%s", out)
+	}
+
+	out := dump()
+	if !strings.Contains(out, "visible") || !strings.Contains(out, "-v") {
+		t.Errorf("dump redacts too much:\n%s", out)
+	}
+}
+
+func TestSecretRedactArgv(t *testing.T) {
+	secrets := map[Arg]SecretPolicy{
+		Long("token"):     SecretAllowInline,
+		Short('k'):        SecretAllowInline,
+		Short('2'):        SecretAllowInline,
+		Plus('p'):         SecretAllowInline,
+		PlusLong("token"): SecretAllowInline,
+	}
+
+	tests := []struct {
+		desc   string
+		setup  func(p *Parser)
+		argv   []string
+		expect []string
+	}{
+		{
+			desc:   "confusables ignored",
+			argv:   []string{"—token=hunter2", "—k", "x"},
+			expect: []string{"—token=hunter2", "—k", "x"},
+		},
+		{
+			desc:   "confusables normalized",
+			setup:  func(p *Parser) { p.Confusables = ConfusablesNormalize },
+			argv:   []string{"—token=hunter2", "–token", "hunter3", "–k", "hunter4", " -khunter5"},
+			expect: []string{"—token=[REDACTED]", "–token", "[REDACTED]", "–k", "[REDACTED]", " -k[REDACTED]"},
+		},
+		{
+			desc:   "confusable double dash",
+			setup:  func(p *Parser) { p.Confusables = ConfusablesNormalize },
+			argv:   []string{"—", "--token", "x"},
+			expect: []string{"—", "--token", "x"},
+		},
+		{
+			desc:   "plus options",
+			setup:  func(p *Parser) { p.PlusOptions = true },
+			argv:   []string{"+p", "hunter2", "++token=hunter3", "+vphunter4", "++token", "hunter5", "+k", "x"},
+			expect: []string{"+p", "[REDACTED]", "++token=[REDACTED]", "+vp[REDACTED]", "++token", "[REDACTED]", "+k", "x"},
+		},
+		{
+			desc:   "plus options off",
+			argv:   []string{"+p", "hunter2", "++token=hunter3"},
+			expect: []string{"+p", "hunter2", "++token=hunter3"},
+		},
+		{
+			desc:   "number options",
+			setup:  func(p *Parser) { p.NumberOptions = true },
+			argv:   []string{"-20", "x", "-2", "x", "-12k", "hunter2"},
+			expect: []string{"-20", "x", "-2", "x", "-12k", "[REDACTED]"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			p := NewFromArgs(test.argv)
+			p.Secrets = secrets
+			if test.setup != nil {
+				test.setup(p)
+			}
+
+			if got := p.redactArgv(); !slices.Equal(got, test.expect) {
+				t.Errorf("want %q, got %q", test.expect, got)
+			}
+
+			if slices.Equal(test.argv, test.expect) {
+				return
+			}
+
+			for p.Next() {
+				var w strings.Builder
+				p.dumpState(&w)
+				if out := w.String(); strings.Contains(out, "hunter") {
+					t.Errorf("dump leaks a secret:\n%s", out)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretErrors(t *testing.T) {
+	secrets := map[Arg]SecretPolicy{Long("token"): SecretAllowInline, Short('k'): SecretAllowInline}
+
+	// An unconsumed secret value is itself the error.
+	p := NewFromArgs([]string{"--token=hunter2", "-k=hunter3", "x"})
+	p.Secrets = secrets
+	p.CollectErrors = true
+	for p.Next() {
+	}
+
+	var w strings.Builder
+	p.dumpState(&w)
+	if out := w.String(); strings.Contains(out, "hunter") || !strings.Contains(out, "unexpected value") {
+		t.Errorf("dump leaks a secret in an error:\n%s", out)
+	}
+
+	expect := `"=[REDACTED]" in "--token=[REDACTED]" (argument 1): unexpected value`
+	if got := p.redactErr(p.redactArgv(), p.Err()); !strings.HasPrefix(got, expect) {
+		t.Errorf("want error starting %s, got %s", expect, got)
+	}
+
+	// A conversion error quotes the secret.
+	p = NewFromArgs([]string{"--token", "hunter2"})
+	p.Secrets = secrets
+	p.Next()
+	val, _ := p.Value()
+	_, err := val.Int()
+
+	expect = `"[REDACTED]" (argument 2): strconv.ParseInt: parsing "[REDACTED]": invalid syntax`
+	if got := p.redactErr(p.redactArgv(), p.WrapErr(err)); got != expect {
+		t.Errorf("want %s, got %s", expect, got)
+	}
+}
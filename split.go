@@ -0,0 +1,123 @@
+package lexopt
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// errUnterminatedQuote and errTrailingBackslash are returned by splitQuoted
+// for malformed input.
+var (
+	errUnterminatedQuote = errors.New("unterminated quote")
+	errTrailingBackslash = errors.New("trailing backslash")
+)
+
+// splitQuoted splits s around each occurrence of sep that is not escaped with
+// a backslash or inside single or double quotes, into at most n fields (or
+// all of them if n < 0), like strings.SplitN.
+//
+// If unquote is true, the quotes and escaping backslashes are removed from
+// the fields; otherwise they're kept, so that the fields can be split again.
+// Within double quotes, a backslash escapes the next character; within single
+// quotes, everything is literal.
+func splitQuoted(s, sep string, n int, unquote bool) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	var quote byte
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case quote == '\'' && c != '\'':
+			field.WriteByte(c)
+			continue
+
+		case c == '\\':
+			if i+1 == len(s) {
+				return nil, errTrailingBackslash
+			}
+			if !unquote {
+				field.WriteByte(c)
+			}
+			i++
+			field.WriteByte(s[i])
+			continue
+
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+			if !unquote {
+				field.WriteByte(c)
+			}
+			continue
+
+		case quote == c:
+			quote = 0
+			if !unquote {
+				field.WriteByte(c)
+			}
+			continue
+
+		case quote == 0 && sep != "" && n != len(fields)+1 && strings.HasPrefix(s[i:], sep):
+			fields = append(fields, field.String())
+			field.Reset()
+			i += len(sep) - 1
+			continue
+		}
+
+		field.WriteByte(c)
+	}
+
+	if quote != 0 {
+		return nil, errUnterminatedQuote
+	}
+
+	return append(fields, field.String()), nil
+}
+
+// trimUnquoted removes the white space around field, one of the fields from
+// splitQuoted with unquote false, except for white space that is escaped with
+// a backslash or inside quotes.
+func trimUnquoted(field string) string {
+	start, end := -1, 0
+	var quote byte
+
+	for i := 0; i < len(field); i++ {
+		from := i
+		c := field[i]
+
+		switch {
+		case quote == '\'' && c != '\'':
+			// literal
+
+		case c == '\\':
+			// splitQuoted made sure there is a character to escape.
+			i++
+
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+
+		case quote == c:
+			quote = 0
+
+		case quote == 0:
+			r, size := utf8.DecodeRuneInString(field[i:])
+			if unicode.IsSpace(r) {
+				i += size - 1
+				continue
+			}
+		}
+
+		if start < 0 {
+			start = from
+		}
+		end = i + 1
+	}
+
+	if start < 0 {
+		return ""
+	}
+	return field[start:end]
+}
@@ -0,0 +1,251 @@
+package lexopt
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LongDuration is like [Arg.Duration], but also accepts days (d) and weeks
+// (w), as in 7d or 2w3d12h. A day is always exactly 24 hours. Like Duration,
+// it accepts fractions and a leading sign, as in 1.5d or -2w.
+func (a Arg) LongDuration() (time.Duration, error) {
+	return parseLongDuration(a.s)
+}
+
+// MustLongDuration is like [Arg.LongDuration], but panics if the conversion
+// fails.
+func (a Arg) MustLongDuration() time.Duration { return must(a.LongDuration()) }
+
+// parseLongDuration does the work for LongDuration, by handing everything but
+// days and weeks to time.ParseDuration.
+func parseLongDuration(s string) (time.Duration, error) {
+	syntaxErr := &strconv.NumError{Func: "LongDuration", Num: s, Err: strconv.ErrSyntax}
+	rangeErr := &RangeError{Value: s, Type: "time.Duration"}
+
+	rest := s
+	neg := strings.HasPrefix(rest, "-")
+	rest = strings.TrimLeft(rest, "+-")
+	if len(s)-len(rest) > 1 || rest == "" {
+		return 0, syntaxErr
+	}
+
+	if rest == "0" {
+		return 0, nil
+	}
+
+	var total time.Duration
+	for rest != "" {
+		unitStart := len(rest) - len(strings.TrimLeft(rest, "0123456789."))
+		num, afterNum := rest[:unitStart], rest[unitStart:]
+		unitEnd := strings.IndexAny(afterNum, "0123456789.")
+		if unitEnd < 0 {
+			unitEnd = len(afterNum)
+		}
+		unit := afterNum[:unitEnd]
+		rest = afterNum[unitEnd:]
+
+		if num == "" || unit == "" {
+			return 0, syntaxErr
+		}
+
+		var d time.Duration
+		switch unit {
+		case "d", "w":
+			f, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, syntaxErr
+			}
+
+			scale := 24 * time.Hour
+			if unit == "w" {
+				scale *= 7
+			}
+
+			if f*float64(scale) >= float64(1<<63) {
+				return 0, rangeErr
+			}
+			d = time.Duration(f * float64(scale))
+
+		default:
+			var err error
+			if d, err = time.ParseDuration(num + unit); err != nil {
+				// ParseDuration doesn't say why it failed, but if the number and
+				// unit are both fine, it must have overflowed.
+				if _, ferr := strconv.ParseFloat(num, 64); ferr == nil && durationUnits[unit] {
+					return 0, rangeErr
+				}
+				return 0, syntaxErr
+			}
+		}
+
+		if total+d < total {
+			return 0, rangeErr
+		}
+		total += d
+	}
+
+	if neg {
+		total = -total
+	}
+
+	return total, nil
+}
+
+// durationUnits are the units that time.ParseDuration accepts.
+var durationUnits = map[string]bool{
+	"ns": true, "us": true, "µs": true, "μs": true, "ms": true, "s": true, "m": true, "h": true,
+}
+
+// defaultTimeLayouts are the layouts tried by Time when none are given.
+var defaultTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Time converts Arg to a [time.Time]. It is exactly like [Arg.TimeFrom],
+// with relative times resolved against the current time.
+func (a Arg) Time(layouts ...string) (time.Time, error) {
+	return a.TimeFrom(time.Now(), layouts...)
+}
+
+// MustTime is like [Arg.Time], but panics if the conversion fails.
+func (a Arg) MustTime(layouts ...string) time.Time { return must(a.Time(layouts...)) }
+
+// TimeFrom converts Arg to a [time.Time], resolving relative times against
+// now. It accepts:
+//
+//   - now, today, yesterday and tomorrow (the last three meaning midnight)
+//   - a [Arg.LongDuration] with a sign, like -2h or +1d, or followed by ago,
+//     like 1h30m ago
+//   - a time in one of layouts, as for [time.Parse]; if none are given, RFC
+//     3339 (2006-01-02T15:04:05Z07:00) and shorter forms without a time zone
+//     or without seconds, down to just a date (2006-01-02)
+//   - a Unix time in seconds, like 1700000000, or in milliseconds if it's too
+//     large to be a plausible number of seconds (beyond the year 5000)
+//
+// Layouts are tried before Unix times, so that a value matching a layout
+// made of digits, like 20060102, is never mistaken for one. Times without a
+// time zone are taken to be in now's location.
+func (a Arg) TimeFrom(now time.Time, layouts ...string) (time.Time, error) {
+	s := strings.TrimSpace(a.s)
+
+	y, m, d := now.Date()
+	switch strings.ToLower(s) {
+	case "now":
+		return now, nil
+	case "today":
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), nil
+	case "yesterday":
+		return time.Date(y, m, d-1, 0, 0, 0, 0, now.Location()), nil
+	case "tomorrow":
+		return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location()), nil
+	}
+
+	if ago, ok := strings.CutSuffix(s, " ago"); ok {
+		if d, err := parseLongDuration(strings.TrimSpace(ago)); err == nil {
+			return now.Add(-d), nil
+		}
+	}
+
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		if d, err := parseLongDuration(s); err == nil {
+			return now.Add(d), nil
+		}
+	}
+
+	if len(layouts) == 0 {
+		layouts = defaultTimeLayouts
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		const maxSeconds = 1e11 // about the year 5138
+		if n >= maxSeconds || n <= -maxSeconds {
+			return time.UnixMilli(n).In(now.Location()), nil
+		}
+		return time.Unix(n, 0).In(now.Location()), nil
+	}
+
+	return time.Time{}, &ParseError{Value: a.s, Want: "a time, like " + strings.Join(layoutExamples(layouts), " or ")}
+}
+
+// MustTimeFrom is like [Arg.TimeFrom], but panics if the conversion fails.
+func (a Arg) MustTimeFrom(now time.Time, layouts ...string) time.Time {
+	return must(a.TimeFrom(now, layouts...))
+}
+
+// layoutExamples returns the first couple of layouts, for error messages.
+func layoutExamples(layouts []string) []string {
+	if len(layouts) > 2 {
+		layouts = layouts[:2]
+	}
+
+	examples := make([]string, len(layouts))
+	for i, layout := range layouts {
+		examples[i] = strconv.Quote(layout)
+	}
+	return examples
+}
+
+// Location converts Arg to a [time.Location]. It accepts the names in the
+// IANA time zone database, like America/New_York, as well as UTC and Local
+// (see [time.LoadLocation]), and fixed offsets from UTC, like +05:30, -0800,
+// +9 or UTC-3.
+func (a Arg) Location() (*time.Location, error) {
+	if loc, ok := fixedZone(a.s); ok {
+		return loc, nil
+	}
+
+	loc, err := time.LoadLocation(a.s)
+	if err != nil || a.s == "" {
+		return nil, &ParseError{Value: a.s, Want: "a time zone name or UTC offset", Err: err}
+	}
+
+	return loc, nil
+}
+
+// MustLocation is like [Arg.Location], but panics if the conversion fails.
+func (a Arg) MustLocation() *time.Location { return must(a.Location()) }
+
+// fixedZone parses s as an offset from UTC, as described for Location.
+func fixedZone(s string) (*time.Location, bool) {
+	offset := strings.TrimPrefix(strings.TrimPrefix(s, "UTC"), "GMT")
+	if offset == "" || (offset[0] != '+' && offset[0] != '-') {
+		return nil, false
+	}
+
+	sign := 1
+	if offset[0] == '-' {
+		sign = -1
+	}
+
+	hh, mm, hasColon := strings.Cut(offset[1:], ":")
+	if !hasColon && len(hh) == 4 {
+		hh, mm = hh[:2], hh[2:]
+	}
+
+	hours, err := strconv.ParseUint(hh, 10, 8)
+	if err != nil || len(hh) > 2 || hours > 23 {
+		return nil, false
+	}
+
+	var minutes uint64
+	if mm != "" || hasColon {
+		minutes, err = strconv.ParseUint(mm, 10, 8)
+		if err != nil || len(mm) != 2 || minutes > 59 {
+			return nil, false
+		}
+	}
+
+	return time.FixedZone(s, sign*int(hours*3600+minutes*60)), true
+}
@@ -0,0 +1,164 @@
+package lexopt
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLongDuration(t *testing.T) {
+	const day = 24 * time.Hour
+
+	good := map[string]time.Duration{
+		"0":        0,
+		"7d":       7 * day,
+		"2w":       14 * day,
+		"2w3d12h":  17*day + 12*time.Hour,
+		"1.5d":     36 * time.Hour,
+		"-2w":      -14 * day,
+		"+1d":      day,
+		"1h30m":    90 * time.Minute,
+		"1d500ms":  day + 500*time.Millisecond,
+		"106751d":  106751 * day,
+		"1d1h1m1s": day + time.Hour + time.Minute + time.Second,
+	}
+
+	for in, expect := range good {
+		a := Value(in)
+		runConvOk(t, a, in, expect, a.LongDuration, a.MustLongDuration)
+	}
+
+	for _, in := range []string{"", "d", "7", "7x", "1d2", "--1d", "+-1d", "1.2.3d", "7 d"} {
+		a := Value(in)
+		runConvErr(t, a, "bad "+in, a.LongDuration, a.MustLongDuration)
+	}
+
+	for _, in := range []string{"106752d", "15251w", "106751d24h", "9999999999h"} {
+		_, err := Value(in).LongDuration()
+		if !errors.Is(err, strconv.ErrRange) {
+			t.Errorf("%s: expected range error, got %v", in, err)
+		}
+	}
+}
+
+func TestTimeFrom(t *testing.T) {
+	loc := time.FixedZone("test", -5*3600)
+	now := time.Date(2024, time.March, 1, 15, 4, 5, 0, loc)
+	midnight := time.Date(2024, time.March, 1, 0, 0, 0, 0, loc)
+
+	good := map[string]time.Time{
+		"now":                       now,
+		"Today":                     midnight,
+		"yesterday":                 midnight.AddDate(0, 0, -1),
+		"tomorrow":                  midnight.AddDate(0, 0, 1),
+		"-2h":                       now.Add(-2 * time.Hour),
+		"+1w":                       now.AddDate(0, 0, 7),
+		"1h30m ago":                 now.Add(-90 * time.Minute),
+		"2d ago":                    now.AddDate(0, 0, -2),
+		"1700000000":                time.Unix(1700000000, 0),
+		"1700000000123":             time.UnixMilli(1700000000123),
+		"2023-11-14T22:13:20Z":      time.Unix(1700000000, 0),
+		"2023-11-14T22:13:20.5Z":    time.Unix(1700000000, 5e8),
+		"2023-11-14T17:13:20-05:00": time.Unix(1700000000, 0),
+		"2023-11-14T17:13:20":       time.Unix(1700000000, 0),
+		"2023-11-14 17:13":          time.Unix(1700000000-20, 0),
+		"2023-11-14":                time.Date(2023, time.November, 14, 0, 0, 0, 0, loc),
+	}
+
+	for in, expect := range good {
+		t.Run(in, func(t *testing.T) {
+			got, err := Value(in).TimeFrom(now)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.Equal(expect) {
+				t.Errorf("want %s, got %s", expect, got)
+			}
+		})
+	}
+
+	for _, in := range []string{"", "never", "2023-13-01", "-2x", "ago", "2023/11/14"} {
+		t.Run("bad "+in, func(t *testing.T) {
+			_, err := Value(in).TimeFrom(now)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected ParseError, got %v", err)
+			}
+
+			if perr.Value != in {
+				t.Errorf("error has wrong value: %q", perr.Value)
+			}
+		})
+	}
+
+	if got := Value("2023-11-14 17:13").MustTimeFrom(now); got.Location() != loc {
+		t.Errorf("times without a zone should be in now's location, got %s", got.Location())
+	}
+
+	if got := Value("1700000000").MustTimeFrom(now); got.Location() != loc {
+		t.Errorf("Unix times should be in now's location, got %s", got.Location())
+	}
+
+	got, err := Value("14/11/2023").TimeFrom(now, "02/01/2006")
+	if err != nil {
+		t.Fatalf("custom layout: unexpected error: %s", err)
+	}
+	if expect := time.Date(2023, time.November, 14, 0, 0, 0, 0, loc); !got.Equal(expect) {
+		t.Errorf("custom layout: want %s, got %s", expect, got)
+	}
+
+	if _, err := Value("2023-11-14").TimeFrom(now, "02/01/2006"); err == nil {
+		t.Errorf("custom layouts should replace the defaults")
+	}
+
+	digits := map[string]time.Time{
+		"2006":     time.Date(2024, time.January, 1, 0, 0, 0, 0, loc),
+		"20060102": time.Date(2024, time.January, 2, 0, 0, 0, 0, loc),
+	}
+
+	for layout, expect := range digits {
+		in := expect.Format(layout)
+		if got := Value(in).MustTimeFrom(now, layout); !got.Equal(expect) {
+			t.Errorf("layout %s: %s should not be a Unix time, want %s, got %s", layout, in, expect, got)
+		}
+	}
+
+	if got := Value("1700000000").MustTimeFrom(now, "2006"); !got.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Unix times should still work with custom layouts, got %s", got)
+	}
+
+	if got := Value("now").MustTime(); time.Since(got) > time.Minute {
+		t.Errorf("Time should resolve against the current time, got %s", got)
+	}
+}
+
+func TestLocation(t *testing.T) {
+	offsets := map[string]int{
+		"+05:30":    5*3600 + 30*60,
+		"-0800":     -8 * 3600,
+		"+9":        9 * 3600,
+		"UTC-3":     -3 * 3600,
+		"GMT+01:00": 3600,
+	}
+
+	for in, expect := range offsets {
+		t.Run(in, func(t *testing.T) {
+			loc := Value(in).MustLocation()
+			_, offset := time.Date(2024, 1, 1, 0, 0, 0, 0, loc).Zone()
+			if offset != expect {
+				t.Errorf("want offset %d, got %d", expect, offset)
+			}
+		})
+	}
+
+	if loc := Value("UTC").MustLocation(); loc != time.UTC {
+		t.Errorf("UTC: got %s", loc)
+	}
+
+	for _, in := range []string{"", "Nowhere/Special", "+24", "+05:60", "+5:3", "UTC+"} {
+		a := Value(in)
+		runConvErr(t, a, "bad "+in, a.Location, a.MustLocation)
+	}
+}
@@ -0,0 +1,186 @@
+package lexopt
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+)
+
+// TokenKind says what a [Token] is.
+type TokenKind int
+
+const (
+	// TokenOption is an option, like -v, --name or +x.
+	TokenOption TokenKind = iota
+
+	// TokenValue is the value of the option before it: either attached to
+	// the option, as in --name=value, or, if Tokenize was told which options
+	// take values, the rest of a short option cluster or the next argument.
+	TokenValue
+
+	// TokenPositional is a positional argument.
+	TokenPositional
+
+	// TokenPlain is an argument right after an option, which is either that
+	// option's value or a positional argument. Tokenize can only tell which
+	// if it's told which options take values.
+	TokenPlain
+
+	// TokenSeparator is the -- that ends the options.
+	TokenSeparator
+
+	// TokenError is something the parser rejects; Token.Err says why.
+	TokenError
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenOption:
+		return "option"
+	case TokenValue:
+		return "value"
+	case TokenPositional:
+		return "positional"
+	case TokenPlain:
+		return "plain"
+	case TokenSeparator:
+		return "separator"
+	case TokenError:
+		return "error"
+	default:
+		return "TokenKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Token is one piece of a command line, as returned by [Tokenize].
+type Token struct {
+	Kind TokenKind
+
+	// Arg is the option, for TokenOption, and the argument itself for
+	// TokenValue, TokenPositional and TokenPlain. It is empty for the
+	// others.
+	Arg Arg
+
+	// Attached is set for a TokenValue that is in the same argument as its
+	// option, as in --name=value or -ovalue.
+	Attached bool
+
+	// Pos is where the token is on the command line.
+	Pos Position
+
+	// Err is why a TokenError was rejected.
+	Err error
+}
+
+// Tokenize splits argv, which does not include the binary name, into tokens,
+// following exactly the rules of [Parser.Next] and [Parser.Value] with the
+// default configuration. See [Parser.Tokenize] for details.
+func Tokenize(argv []string, takesValue ...Arg) []Token {
+	return NewFromArgs(argv).Tokenize(takesValue...)
+}
+
+// Tokenize splits the parser's command line into tokens, without the need
+// to drive a loop over [Parser.Next]. It uses the parser's configuration,
+// like [Parser.PlusOptions], but not its position: it always starts from the
+// first argument, and leaves the parser as it was. Errors are collected, as
+// with [Parser.CollectErrors], and returned as TokenError tokens.
+//
+// On its own, a command line doesn't say whether -o x means -o with a value
+// x, or -o followed by a positional argument x; the x is a TokenPlain. If the
+// options that take values are given as takesValue, Tokenize decides as a
+// program calling [Parser.Value] for those options would, and every
+// argument is either a TokenValue or a TokenPositional.
+func (p *Parser) Tokenize(takesValue ...Arg) []Token {
+	q := *p
+	q.Trace = nil
+	q.CollectErrors = true
+	q.Current = Arg{}
+	q.cursor = cursor{argv: p.argv}
+
+	known := len(takesValue) > 0
+	var tokens []Token
+	afterOption := false
+
+	// errorsSince adds the errors recorded since there were n of them.
+	errorsSince := func(n int) {
+		for _, err := range q.errs[n:] {
+			tok := Token{Kind: TokenError, Err: err}
+
+			var argErr *ArgError
+			if errors.As(err, &argErr) {
+				tok.Pos = argErr.Pos
+			}
+
+			tokens = append(tokens, tok)
+		}
+	}
+
+	// separator adds the -- at argv[idx].
+	separator := func(idx int) {
+		tokens = append(tokens, Token{Kind: TokenSeparator, Pos: wholeToken(q.argv, idx)})
+		afterOption = false
+	}
+
+	for {
+		nerrs, before := len(q.errs), q.state
+		ok := q.Next()
+		errorsSince(nerrs)
+
+		if before != finished && q.state == finished {
+			if ok {
+				separator(q.curpos.Index - 1)
+			} else {
+				separator(len(q.argv) - 1)
+			}
+		}
+
+		if !ok {
+			break
+		}
+
+		arg := q.Current
+		if !arg.IsOption() {
+			kind := TokenPositional
+			if afterOption && q.state != finished {
+				kind = TokenPlain
+			}
+
+			tokens = append(tokens, Token{Kind: kind, Arg: arg, Pos: q.curpos})
+			afterOption = false
+			continue
+		}
+
+		tokens = append(tokens, Token{Kind: TokenOption, Arg: arg, Pos: q.curpos})
+
+		attached := q.state == pendingValue || q.state == short && q.short[q.shortpos] == '='
+		takes := slices.Contains(takesValue, arg)
+		afterOption = false
+
+		switch {
+		case known && takes:
+			hadPending := q.hasPending()
+			nerrs := len(q.errs)
+			val, err := q.Value()
+			if err != nil {
+				q.errs = append(q.errs, err)
+				errorsSince(nerrs)
+				break
+			}
+
+			tokens = append(tokens, Token{Kind: TokenValue, Arg: val, Attached: hadPending, Pos: q.pos})
+
+		case known:
+			// Leave any attached value for Next to reject, as it would in a
+			// program that doesn't call Value for this option.
+
+		case attached:
+			val, _ := q.OptionalValue()
+			tokens = append(tokens, Token{Kind: TokenValue, Arg: val, Attached: true, Pos: q.pos})
+
+		default:
+			afterOption = q.state != short
+		}
+	}
+
+	return tokens
+}
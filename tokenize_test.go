@@ -0,0 +1,218 @@
+package lexopt
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// tokenString renders tokens compactly, for comparing in tests.
+func tokenString(tokens []Token) string {
+	var parts []string
+	for _, tok := range tokens {
+		s := tok.Kind.String()
+		switch {
+		case tok.Kind == TokenError:
+			s += "(" + tok.Pos.Text() + ")"
+		case tok.Kind == TokenSeparator:
+		case tok.Attached:
+			s += "=" + tok.Arg.DashedString()
+		default:
+			s += ":" + tok.Arg.DashedString()
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		argv   []string
+		takes  []Arg
+		expect string
+	}{
+		{
+			[]string{"-v", "file", "--out=x", "--in", "y", "pos"},
+			nil,
+			"option:-v plain:file option:--out value=x option:--in plain:y positional:pos",
+		},
+		{
+			[]string{"-v", "file", "--out=x", "--in", "y", "pos"},
+			[]Arg{Long("out"), Long("in")},
+			"option:-v positional:file option:--out value=x option:--in value:y positional:pos",
+		},
+		{
+			[]string{"-abc", "x", "-o=y"},
+			nil,
+			"option:-a option:-b option:-c plain:x option:-o value=y",
+		},
+		{
+			[]string{"-abc", "x", "-ofile"},
+			[]Arg{Short('b'), Short('o')},
+			"option:-a option:-b value=c positional:x option:-o value=file",
+		},
+		{
+			[]string{"-o", "--", "a", "--", "-b"},
+			nil,
+			"option:-o separator positional:a positional:-- positional:-b",
+		},
+		{
+			[]string{"-o", "--", "a"},
+			[]Arg{Short('o')},
+			"option:-o value:-- positional:a",
+		},
+		{
+			[]string{"a", "-", "--"},
+			nil,
+			"positional:a positional:- separator",
+		},
+		{
+			[]string{"--flag=x", "-o"},
+			[]Arg{Short('o')},
+			"option:--flag error(=x) option:-o error(-o)",
+		},
+		{
+			[]string{},
+			nil,
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		name := fmt.Sprint(test.argv, test.takes)
+		t.Run(name, func(t *testing.T) {
+			if got := tokenString(Tokenize(test.argv, test.takes...)); got != test.expect {
+				t.Errorf("\nwant %s\ngot  %s", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestTokenizeConfig(t *testing.T) {
+	p := NewFromArgs([]string{"-20", "+x", "++Long=v", "—verbose", "—n"})
+	p.NumberOptions = true
+	p.PlusOptions = true
+	p.NormalizeLong = FoldCase
+	p.Confusables = ConfusablesReject
+	p.Next()
+
+	expect := "option:-20 option:+x option:++long value=v error(—verbose) error(—n)"
+	if got := tokenString(p.Tokenize()); got != expect {
+		t.Errorf("\nwant %s\ngot  %s", expect, got)
+	}
+
+	if p.Current != Number(20) || p.idx != 1 {
+		t.Errorf("Tokenize should leave the parser as it was")
+	}
+
+	tokens := Tokenize([]string{"--name=v", "-abc"})
+	positions := []Position{
+		{Index: 0, Start: 0, End: 6, Token: "--name=v"},
+		{Index: 0, Start: 7, End: 8, Token: "--name=v"},
+		{Index: 1, Start: 0, End: 2, Token: "-abc"},
+		{Index: 1, Start: 2, End: 3, Token: "-abc"},
+		{Index: 1, Start: 3, End: 4, Token: "-abc"},
+	}
+
+	for i, tok := range tokens {
+		if tok.Pos != positions[i] {
+			t.Errorf("token %d: want %+v, got %+v", i, positions[i], tok.Pos)
+		}
+	}
+}
+
+// TestTokenizeMatchesParser checks that Tokenize agrees with a program that
+// drives a Parser in the usual way, calling Value for the options that take
+// values, so that the two can't drift apart.
+func TestTokenizeMatchesParser(t *testing.T) {
+	takes := []Arg{Short('o'), Long("out"), Plus('p'), Number(5)}
+
+	argvs := [][]string{
+		{"-o", "x", "y"},
+		{"-vo", "x", "-ox", "-o=x", "--out", "-v", "--out=", "--verbose"},
+		{"-o"},
+		{"--out"},
+		{"-v", "--", "-o", "x"},
+		{"-o", "--", "x"},
+		{"--verbose=yes", "-v=x", "-vv=x"},
+		{"+p", "x", "+vp", "++long=y", "+", "++"},
+		{"-5", "x", "-52", "-5v", "-v5"},
+		{"—out", "x", "–o", "-", ""},
+		{"-", "--", "--", "-"},
+		{"-v", "—", "-v"},
+		{"-oé", "-éo", "--é=ü"},
+	}
+
+	configs := map[string]func(*Parser){
+		"default":    func(p *Parser) {},
+		"plus":       func(p *Parser) { p.PlusOptions = true },
+		"number":     func(p *Parser) { p.NumberOptions = true },
+		"normalize":  func(p *Parser) { p.Confusables = ConfusablesNormalize },
+		"reject":     func(p *Parser) { p.Confusables = ConfusablesReject },
+		"fold kebab": func(p *Parser) { p.NormalizeLong = FoldKebab },
+	}
+
+	for _, argv := range argvs {
+		for name, config := range configs {
+			t.Run(fmt.Sprint(name, argv), func(t *testing.T) {
+				p := NewFromArgs(argv)
+				config(p)
+				tokens := p.Tokenize(takes...)
+
+				// What a program would see.
+				p = NewFromArgs(argv)
+				config(p)
+				p.CollectErrors = true
+
+				var want []string
+				var wantErrs []error
+				for p.Next() {
+					want = append(want, p.Current.DashedString()+" "+p.CurrentPosition().String())
+					if slices.Contains(takes, p.Current) {
+						if val, err := p.Value(); err == nil {
+							want = append(want, val.DashedString()+" "+p.Position().String())
+						} else {
+							wantErrs = append(wantErrs, err)
+						}
+					}
+				}
+
+				if err := p.Err(); err != nil {
+					var joined interface{ Unwrap() []error }
+					if errors.As(err, &joined) {
+						wantErrs = append(wantErrs, joined.Unwrap()...)
+					} else {
+						wantErrs = append(wantErrs, err)
+					}
+				}
+
+				var got []string
+				var gotErrs int
+				for _, tok := range tokens {
+					switch tok.Kind {
+					case TokenError:
+						gotErrs++
+					case TokenSeparator:
+						if fixed, _ := fixConfusable(tok.Pos.Token); tok.Pos.Token != "--" && fixed != "--" {
+							t.Errorf("separator at %s", tok.Pos)
+						}
+					case TokenPlain:
+						t.Errorf("plain token with known options: %+v", tok)
+					default:
+						got = append(got, tok.Arg.DashedString()+" "+tok.Pos.String())
+					}
+				}
+
+				if !slices.Equal(got, want) {
+					t.Errorf("\nwant %q\ngot  %q", want, got)
+				}
+
+				if gotErrs != len(wantErrs) {
+					t.Errorf("want %d errors, got %d: %v", len(wantErrs), gotErrs, wantErrs)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package lexopt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+)
+
+func (s state) String() string {
+	switch s {
+	case empty:
+		return "empty"
+	case short:
+		return "short"
+	case pendingValue:
+		return "pendingValue"
+	case finished:
+		return "finished"
+	default:
+		return "state(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// A traceStep is what beginTrace remembers about the parser, for endTrace to
+// compare against.
+type traceStep struct {
+	op    string
+	state state
+	idx   int
+	nerrs int
+	err   error
+}
+
+// beginTrace notes the parser's state before a step, if tracing is on.
+func (p *Parser) beginTrace(op string) *traceStep {
+	if p.Trace == nil || p.quiet {
+		return nil
+	}
+
+	return &traceStep{op: op, state: p.state, idx: p.idx, nerrs: len(p.errs), err: p.err}
+}
+
+// endTrace logs a step that began with beginTrace. The arg is the one the
+// step produced, if any, and err is the error it returned, if any; errors
+// recorded on the parser are found by endTrace itself.
+func (p *Parser) endTrace(step *traceStep, arg Arg, err error) {
+	if step == nil {
+		return
+	}
+
+	if err == nil {
+		switch {
+		case len(p.errs) > step.nerrs:
+			err = p.errs[len(p.errs)-1]
+		case p.err != step.err:
+			err = p.err
+		}
+	}
+
+	argv := p.redactArgv()
+	attrs := []slog.Attr{
+		slog.Any("tokens", argv[step.idx:max(step.idx, p.idx)]),
+		slog.String("before", step.state.String()),
+		slog.String("after", p.state.String()),
+	}
+
+	if arg != (Arg{}) {
+		// A value is secret if it belongs to a secret option, or it comes from
+		// a token that redactArgv hid.
+		_, secret := p.Secrets[p.Current]
+		hidden := secret && step.op != "Next" || p.hidden(argv, p.pos)
+
+		switch {
+		case arg.IsOption():
+			attrs = append(attrs, slog.String("arg", arg.DashedString()))
+		case hidden:
+			attrs = append(attrs, slog.String("arg", redacted))
+		default:
+			attrs = append(attrs, slog.String("arg", strconv.Quote(arg.s)))
+		}
+
+		if hidden {
+			attrs = append(attrs, slog.Int("index", p.pos.Index))
+		} else {
+			attrs = append(attrs, slog.String("pos", p.pos.String()))
+		}
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("err", p.redactErr(argv, err)))
+	}
+
+	p.Trace.LogAttrs(context.Background(), slog.LevelDebug, "lexopt: "+step.op, attrs...)
+}
+
+// hidden reports whether pos is in a token that redactArgv changed. The argv
+// is the result of p.redactArgv().
+func (p *Parser) hidden(argv []string, pos Position) bool {
+	return pos.Index < len(argv) && argv[pos.Index] != p.argv[pos.Index]
+}
+
+// Explain writes a breakdown of the parser's whole command line to w,
+// showing how each argument is split into options and values. It is meant
+// for a hidden option, so that users can show how their command line is
+// being read when reporting a problem:
+//
+//	case lexopt.Long("lexopt-explain"):
+//		p.Explain(os.Stderr)
+//		os.Exit(0)
+//
+// Explain uses the parser's configuration, but not its position: it always
+// starts from the first argument, and leaves the parser as it was. Since
+// lexopt doesn't know which options take values, only values attached to an
+// option, as in --name=value, are shown as values; -ovalue is shown as a
+// series of short options, except after an option in [Parser.Secrets].
+// Values of options in Secrets are redacted, including in errors.
+func (p *Parser) Explain(w io.Writer) {
+	q := *p
+	q.Trace = nil
+	q.CollectErrors = true
+	q.Current = Arg{}
+	q.cursor = cursor{argv: p.argv}
+
+	argv := p.redactArgv()
+	printed := 0
+
+	// catchUp prints the arguments before idx that yielded nothing: -- and
+	// any that were rejected with an error.
+	catchUp := func(idx int) {
+		for ; printed < idx; printed++ {
+			note := "rejected"
+			if p.argv[printed] == "--" {
+				note = "end of options"
+			}
+			fmt.Fprintf(w, "%4d  %-20s %s\n", printed+1, shellQuote(argv[printed]), note)
+		}
+	}
+
+	row := func(idx int, desc string) {
+		num, tok := "", ""
+		if idx >= printed {
+			catchUp(idx)
+			num, tok = strconv.Itoa(idx+1), shellQuote(argv[idx])
+			printed = idx + 1
+		}
+		fmt.Fprintf(w, "%4s  %-20s %s\n", num, tok, desc)
+	}
+
+	fmt.Fprintf(w, "%d arguments:\n", len(p.argv))
+	for q.Next() {
+		pos := q.CurrentPosition()
+
+		var desc string
+		switch q.Current.kind {
+		case argLong, argPlusLong:
+			desc = "long option " + q.DashedCurrent()
+		case argShort:
+			desc = "short option " + q.Current.DashedString()
+		case argPlus:
+			desc = "plus option " + q.Current.DashedString()
+		case argNumber:
+			desc = "number option " + q.Current.DashedString()
+		default:
+			if p.hidden(argv, pos) {
+				desc = "positional argument " + redacted
+			} else {
+				desc = "positional argument " + strconv.Quote(q.Current.s)
+			}
+		}
+		row(pos.Index, desc)
+
+		// Take only values attached with =, which Next would otherwise reject,
+		// and the rest of the cluster after a secret short option, which would
+		// otherwise be shown as options, one character of the secret at a time.
+		_, secret := p.Secrets[q.Current]
+		attached := q.state == pendingValue || q.state == short && q.short[q.shortpos] == '='
+		if attached || secret && q.hasPending() {
+			val, _ := q.OptionalValue()
+			if secret {
+				row(pos.Index, "  with value "+redacted)
+			} else {
+				row(pos.Index, "  with value "+strconv.Quote(val.s))
+			}
+		}
+	}
+	catchUp(len(p.argv))
+
+	if err := q.Err(); err != nil {
+		fmt.Fprintf(w, "errors: %s\n", p.redactErr(argv, err))
+	}
+}
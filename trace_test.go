@@ -0,0 +1,252 @@
+package lexopt
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// tracedParser returns a parser for argv that traces to the returned buffer,
+// without timestamps.
+func tracedParser(argv ...string) (*Parser, *bytes.Buffer) {
+	var buf bytes.Buffer
+	p := NewFromArgs(argv)
+	p.Trace = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == slog.LevelKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	return p, &buf
+}
+
+func traceOk(t *testing.T, buf *bytes.Buffer, expect ...string) {
+	t.Helper()
+
+	got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(got) != len(expect) {
+		t.Fatalf("want %d lines, got %d:\n%s", len(expect), len(got), buf)
+	}
+
+	for i := range expect {
+		if got[i] != expect[i] {
+			t.Errorf("line %d:\nwant %s\ngot  %s", i+1, expect[i], got[i])
+		}
+	}
+
+	buf.Reset()
+}
+
+func TestTrace(t *testing.T) {
+	p, buf := tracedParser("-ab", "--file=x", "pos", "--list", "1", "2", "--", "-z")
+
+	p.Next()
+	p.Next()
+	traceOk(t, buf,
+		`msg="lexopt: Next" tokens=[-ab] before=empty after=short arg=-a pos="\"-a\" in \"-ab\" (argument 1)"`,
+		`msg="lexopt: Next" tokens=[] before=short after=empty arg=-b pos="\"b\" in \"-ab\" (argument 1)"`,
+	)
+
+	if _, ok := p.Peek(); !ok {
+		t.Fatal("Peek failed")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Peek should not be traced, got %s", buf)
+	}
+
+	p.Next()
+	p.Value()
+	traceOk(t, buf,
+		`msg="lexopt: Next" tokens="[--file=x]" before=empty after=pendingValue arg=--file pos="\"--file\" in \"--file=x\" (argument 2)"`,
+		`msg="lexopt: Value" tokens=[] before=pendingValue after=empty arg="\"x\"" pos="\"x\" in \"--file=x\" (argument 2)"`,
+	)
+
+	p.Next()
+	p.Next()
+	p.Values()
+	traceOk(t, buf,
+		`msg="lexopt: Next" tokens=[pos] before=empty after=empty arg="\"pos\"" pos="\"pos\" (argument 3)"`,
+		`msg="lexopt: Next" tokens=[--list] before=empty after=empty arg=--list pos="\"--list\" (argument 4)"`,
+		`msg="lexopt: Value" tokens=[1] before=empty after=empty arg="\"1\"" pos="\"1\" (argument 5)"`,
+		`msg="lexopt: Values" tokens=[2] before=empty after=empty arg="\"2\"" pos="\"2\" (argument 6)"`,
+	)
+
+	p.Next()
+	p.Next()
+	traceOk(t, buf,
+		`msg="lexopt: Next" tokens="[-- -z]" before=empty after=finished arg="\"-z\"" pos="\"-z\" (argument 8)"`,
+		`msg="lexopt: Next" tokens=[] before=finished after=finished`,
+	)
+}
+
+func TestTraceErrors(t *testing.T) {
+	p, buf := tracedParser("--flag=x", "--other")
+	p.Next()
+	p.Next()
+	traceOk(t, buf,
+		`msg="lexopt: Next" tokens="[--flag=x]" before=empty after=pendingValue arg=--flag pos="\"--flag\" in \"--flag=x\" (argument 1)"`,
+		`msg="lexopt: Next" tokens=[] before=pendingValue after=pendingValue err="\"=x\" in \"--flag=x\" (argument 1): unexpected value"`,
+	)
+
+	p, buf = tracedParser("-o")
+	p.Next()
+	p.Value()
+	traceOk(t, buf,
+		`msg="lexopt: Next" tokens=[-o] before=empty after=empty arg=-o pos="\"-o\" (argument 1)"`,
+		`msg="lexopt: Value" tokens=[] before=empty after=empty err="\"-o\" (argument 1): no value found"`,
+	)
+
+	p, buf = tracedParser("a", "b")
+	p.Next()
+	raw, _ := p.RawArgs()
+	raw.Next()
+	raw.Next()
+	traceOk(t, buf,
+		`msg="lexopt: Next" tokens=[a] before=empty after=empty arg="\"a\"" pos="\"a\" (argument 1)"`,
+		`msg="lexopt: RawArgs.Next" tokens=[b] before=empty after=empty arg="\"b\"" pos="\"a\" (argument 1)"`,
+		`msg="lexopt: RawArgs.Next" tokens=[] before=empty after=empty`,
+	)
+}
+
+func TestTraceSecrets(t *testing.T) {
+	p, buf := tracedParser("--token", "s3cret1", "--token=s3cret2", "s3cret3")
+	p.Secrets = map[Arg]SecretPolicy{Long("token"): SecretAllowInline}
+
+	p.Next()
+	p.Value()
+	p.Next()
+	p.Value()
+	p.Next()
+
+	if out := buf.String(); strings.Contains(out, "s3cret1") || strings.Contains(out, "s3cret2") {
+		t.Errorf("trace leaks a secret:\n%s", out)
+	}
+
+	if out := buf.String(); !strings.Contains(out, "s3cret3") {
+		t.Errorf("trace redacts too much:\n%s", out)
+	}
+
+	// The secret is itself the error.
+	p, buf = tracedParser("--token=hunter2", "x")
+	p.Secrets = map[Arg]SecretPolicy{Long("token"): SecretAllowInline}
+	p.Next()
+	p.Next()
+	traceOk(t, buf,
+		`msg="lexopt: Next" tokens="[--token=[REDACTED]]" before=empty after=pendingValue arg=--token index=0`,
+		`msg="lexopt: Next" tokens=[] before=pendingValue after=pendingValue err="\"=[REDACTED]\" in \"--token=[REDACTED]\" (argument 1): unexpected value"`,
+	)
+
+	// Plus options and normalized confusables are redacted as Next reads them.
+	p, buf = tracedParser("—token=hunter2", "++token=hunter3", "+p", "hunter4")
+	p.Confusables = ConfusablesNormalize
+	p.PlusOptions = true
+	p.Secrets = map[Arg]SecretPolicy{Long("token"): SecretAllowInline, PlusLong("token"): SecretAllowInline, Plus('p'): SecretAllowInline}
+	p.Next()
+	p.Value()
+	p.Next()
+	p.Value()
+	p.Next()
+	p.Value()
+	traceOk(t, buf,
+		`msg="lexopt: Next" tokens="[—token=[REDACTED]]" before=empty after=pendingValue arg=--token index=0`,
+		`msg="lexopt: Value" tokens=[] before=pendingValue after=empty arg=[REDACTED] index=0`,
+		`msg="lexopt: Next" tokens="[++token=[REDACTED]]" before=empty after=pendingValue arg=++token index=1`,
+		`msg="lexopt: Value" tokens=[] before=pendingValue after=empty arg=[REDACTED] index=1`,
+		`msg="lexopt: Next" tokens=[+p] before=empty after=empty arg=+p pos="\"+p\" (argument 3)"`,
+		`msg="lexopt: Value" tokens=[[REDACTED]] before=empty after=empty arg=[REDACTED] index=3`,
+	)
+}
+
+func TestExplain(t *testing.T) {
+	p := NewFromArgs([]string{"-vx", "--file=a b", "-o=out", "pos", "--Token", "s3cret", "-20", "--", "--not-an-option"})
+	p.NumberOptions = true
+	p.NormalizeLong = FoldCase
+	p.Secrets = map[Arg]SecretPolicy{Long("token"): SecretAllowInline}
+	p.Next()
+
+	var buf bytes.Buffer
+	p.Explain(&buf)
+
+	expect := `9 arguments:
+   1  -vx                  short option -v
+                           short option -x
+   2  '--file=a b'         long option --file
+                             with value "a b"
+   3  -o=out               short option -o
+                             with value "out"
+   4  pos                  positional argument "pos"
+   5  --Token              long option --Token
+   6  '[REDACTED]'         positional argument [REDACTED]
+   7  -20                  number option -20
+   8  --                   end of options
+   9  --not-an-option      positional argument "--not-an-option"
+`
+	if got := buf.String(); got != expect {
+		t.Errorf("want:\n%s\ngot:\n%s", expect, got)
+	}
+
+	if p.Current != Short('v') || p.state != short {
+		t.Errorf("Explain should leave the parser as it was")
+	}
+
+	p = NewFromArgs([]string{"-vpsecret", "--token=hunter2"})
+	p.Secrets = map[Arg]SecretPolicy{Short('p'): SecretAllowInline, Long("token"): SecretAllowInline}
+	buf.Reset()
+	p.Explain(&buf)
+
+	expect = `2 arguments:
+   1  '-vp[REDACTED]'      short option -v
+                           short option -p
+                             with value [REDACTED]
+   2  '--token=[REDACTED]' long option --token
+                             with value [REDACTED]
+`
+	if got := buf.String(); got != expect {
+		t.Errorf("want:\n%s\ngot:\n%s", expect, got)
+	}
+
+	p = NewFromArgs([]string{"—token=hunter2", "++token=hunter3", "+vphunter4", "+p", "hunter5"})
+	p.Confusables = ConfusablesNormalize
+	p.PlusOptions = true
+	p.Secrets = map[Arg]SecretPolicy{Long("token"): SecretAllowInline, PlusLong("token"): SecretAllowInline, Plus('p'): SecretAllowInline}
+	buf.Reset()
+	p.Explain(&buf)
+
+	expect = `5 arguments:
+   1  '—token=[REDACTED]'  long option --token
+                             with value [REDACTED]
+   2  '++token=[REDACTED]' long option ++token
+                             with value [REDACTED]
+   3  '+vp[REDACTED]'      plus option +v
+                           plus option +p
+                             with value [REDACTED]
+   4  +p                   plus option +p
+   5  '[REDACTED]'         positional argument [REDACTED]
+`
+	if got := buf.String(); got != expect {
+		t.Errorf("want:\n%s\ngot:\n%s", expect, got)
+	}
+
+	// Explain doesn't know that --token takes a value, so it rejects it.
+	p = NewFromArgs([]string{"--token", "—hunter2"})
+	p.Secrets = map[Arg]SecretPolicy{Long("token"): SecretAllowInline}
+	p.Confusables = ConfusablesReject
+	buf.Reset()
+	p.Explain(&buf)
+	if got := buf.String(); strings.Contains(got, "hunter") || !strings.Contains(got, "errors: ") {
+		t.Errorf("Explain leaks a secret:\n%s", got)
+	}
+
+	p = NewFromArgs([]string{"—verbose", "--"})
+	p.Confusables = ConfusablesReject
+	buf.Reset()
+	p.Explain(&buf)
+
+	if got := buf.String(); !strings.Contains(got, "1  —verbose             rejected") || !strings.Contains(got, "errors: ") || !strings.Contains(got, "2  --                   end of options") {
+		t.Errorf("rejected arguments not explained:\n%s", got)
+	}
+}
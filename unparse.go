@@ -0,0 +1,188 @@
+package lexopt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Builder builds a command line from options, values and positional
+// arguments, as for passing the options a program was given on to a child
+// process. The result is canonical, and a [Parser] with the same PlusOptions
+// and NumberOptions reads it back the same whatever the values look like:
+//
+//   - a short option with a value is two arguments, as in -n value, which
+//     [Parser.Value] takes even if the value starts with a dash
+//   - a long option with a value is one argument, as in --name=value, so
+//     that no value can be mistaken for an option
+//   - options come first, then the positional arguments, with -- in between
+//     if any of them would otherwise look like an option
+//
+// The zero value is ready to use.
+type Builder struct {
+	// PlusOptions should be set if the command line is for a parser with
+	// [Parser.PlusOptions] set. Plus options can only be added when it is
+	// set, and positional arguments starting with a plus sign are then
+	// protected with --.
+	PlusOptions bool
+
+	// NumberOptions should be set if the command line is for a parser with
+	// [Parser.NumberOptions] set. Number options can only be added when it
+	// is set, and short options that are digits only when it is not.
+	NumberOptions bool
+
+	options     []string
+	positionals []string
+}
+
+// UnparseError is returned by [Builder] when an option can't be written so
+// that a parser reads it back the same.
+type UnparseError struct {
+	Option string // the option, with its dashes
+	Reason string // why it can't be written
+}
+
+func (e *UnparseError) Error() string {
+	return fmt.Sprintf("can't unparse %q: %s", e.Option, e.Reason)
+}
+
+// Option adds an option without a value, like -v or --verbose. It returns an
+// [UnparseError], and adds nothing, if opt is not an option or is one that
+// can't be written on its own, like Long("") or Long("a=b").
+func (b *Builder) Option(opt Arg) error {
+	dashed, err := b.unparseOption(opt, false)
+	if err != nil {
+		return err
+	}
+
+	b.options = append(b.options, dashed)
+	return nil
+}
+
+// OptionValue adds an option with a value, as -n value for short, plus and
+// number options, and --name=value for long ones. For an option that is
+// given more than once, call OptionValue for each. It returns an
+// [UnparseError], and adds nothing, if opt can't be written, as for
+// [Builder.Option]; a Long("") with a value is written as --=value.
+func (b *Builder) OptionValue(opt Arg, value string) error {
+	dashed, err := b.unparseOption(opt, true)
+	if err != nil {
+		return err
+	}
+
+	switch opt.kind {
+	case argLong, argPlusLong:
+		b.options = append(b.options, dashed+"="+value)
+	default:
+		b.options = append(b.options, dashed, value)
+	}
+	return nil
+}
+
+// Positional adds positional arguments, which go after all the options.
+func (b *Builder) Positional(args ...string) {
+	b.positionals = append(b.positionals, args...)
+}
+
+// Argv returns the command line built so far, without a binary name.
+func (b *Builder) Argv() []string {
+	argv := make([]string, 0, len(b.options)+len(b.positionals)+1)
+	argv = append(argv, b.options...)
+
+	for _, arg := range b.positionals {
+		if b.looksLikeOption(arg) {
+			argv = append(argv, "--")
+			break
+		}
+	}
+
+	return append(argv, b.positionals...)
+}
+
+// looksLikeOption reports whether a parser would not take arg as a
+// positional argument, if it appeared before --.
+func (b *Builder) looksLikeOption(arg string) bool {
+	if strings.HasPrefix(arg, "-") && arg != "-" {
+		return true
+	}
+
+	if b.PlusOptions && isPlusOption(arg) {
+		return true
+	}
+
+	// Be safe even if the parser normalizes Unicode dashes.
+	_, confusable := fixConfusable(arg)
+	return confusable
+}
+
+// Unparse returns the arguments for opt and its values, in the canonical
+// form used by [Builder]: just the option if there are no values, and the
+// option with each value otherwise, as if [Builder.OptionValue] had been
+// called for each. The arguments are for a parser that has PlusOptions set
+// if opt is a plus option, and NumberOptions set if it is a number option.
+// It returns an [UnparseError] if opt can't be written.
+func Unparse(opt Arg, values ...string) ([]string, error) {
+	b := Builder{
+		PlusOptions:   opt.kind == argPlus || opt.kind == argPlusLong,
+		NumberOptions: opt.kind == argNumber,
+	}
+	if len(values) == 0 {
+		if err := b.Option(opt); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, val := range values {
+		if err := b.OptionValue(opt, val); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Argv(), nil
+}
+
+// unparseOption returns opt with its dashes, checking that a parser with the
+// builder's settings reads it back the same, with a value following if
+// hasValue is set.
+func (b *Builder) unparseOption(opt Arg, hasValue bool) (string, error) {
+	var reason string
+	switch opt.kind {
+	case argLong, argPlusLong:
+		switch {
+		case strings.Contains(opt.s, "="):
+			reason = "its name contains ="
+		case opt.s == "" && !hasValue:
+			reason = "it has no name"
+		}
+
+	case argShort:
+		switch {
+		case opt.s == "-":
+			reason = "it would read back as --"
+		case b.NumberOptions && countDigits(opt.s) > 0:
+			reason = "it would read back as a number option"
+		}
+
+	case argPlus:
+		if opt.s == "+" {
+			reason = "it would read back as a positional argument"
+		}
+
+	case argNumber:
+		if !b.NumberOptions {
+			reason = "number options need NumberOptions"
+		}
+
+	default:
+		reason = "it is not an option"
+	}
+
+	if (opt.kind == argPlus || opt.kind == argPlusLong) && !b.PlusOptions {
+		reason = "plus options need PlusOptions"
+	}
+
+	if reason != "" {
+		return "", &UnparseError{Option: opt.DashedString(), Reason: reason}
+	}
+
+	return opt.DashedString(), nil
+}
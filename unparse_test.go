@@ -0,0 +1,241 @@
+package lexopt
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	b := Builder{PlusOptions: true, NumberOptions: true}
+	b.Option(Short('v'))
+	b.OptionValue(Short('n'), "-5")
+	b.OptionValue(Long("name"), "--x")
+	b.OptionValue(Long("name"), "")
+	b.OptionValue(Plus('p'), "x")
+	b.OptionValue(PlusLong("max"), "=1")
+	b.OptionValue(Number(20), "y")
+	b.Positional("a", "-")
+
+	expect := []string{"-v", "-n", "-5", "--name=--x", "--name=", "+p", "x", "++max==1", "-20", "y", "a", "-"}
+	if got := b.Argv(); !slices.Equal(got, expect) {
+		t.Errorf("\nwant %q\ngot  %q", expect, got)
+	}
+
+	b.Positional("-b")
+	expect = append(expect[:len(expect)-2], "--", "a", "-", "-b")
+	if got := b.Argv(); !slices.Equal(got, expect) {
+		t.Errorf("\nwant %q\ngot  %q", expect, got)
+	}
+
+	tests := []struct {
+		positionals []string
+		plus        bool
+		separator   bool
+	}{
+		{[]string{"a", "-"}, false, false},
+		{[]string{"a", "--"}, false, true},
+		{[]string{"+x"}, false, false},
+		{[]string{"+x"}, true, true},
+		{[]string{"+", "++"}, true, false},
+		{[]string{"—x"}, false, true},
+	}
+
+	for _, test := range tests {
+		b := Builder{PlusOptions: test.plus}
+		b.Positional(test.positionals...)
+		if got := b.Argv()[0] == "--"; got != test.separator {
+			t.Errorf("%q with plus %v: want separator %v, got %q", test.positionals, test.plus, test.separator, b.Argv())
+		}
+	}
+}
+
+func TestUnparse(t *testing.T) {
+	tests := []struct {
+		opt    Arg
+		values []string
+		expect []string
+	}{
+		{Short('v'), nil, []string{"-v"}},
+		{Short('n'), []string{"1", "-2"}, []string{"-n", "1", "-n", "-2"}},
+		{Long("out"), []string{"-"}, []string{"--out=-"}},
+		{PlusLong("x"), nil, []string{"++x"}},
+	}
+
+	for _, test := range tests {
+		got, err := Unparse(test.opt, test.values...)
+		if err != nil || !slices.Equal(got, test.expect) {
+			t.Errorf("%s %q: want %q, got %q, %v", test.opt.DashedString(), test.values, test.expect, got, err)
+		}
+	}
+
+	for _, opt := range []Arg{Long(""), Long("a=b"), Short('-'), Plus('+'), PlusLong(""), Arg{kind: argPlain, s: "x"}, {}} {
+		var unparseErr *UnparseError
+		if got, err := Unparse(opt); !errors.As(err, &unparseErr) {
+			t.Errorf("%#v: want an UnparseError, got %q, %v", opt, got, err)
+		}
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	tests := []struct {
+		b      Builder
+		opt    Arg
+		expect string
+	}{
+		{Builder{}, Long(""), `can't unparse "--": it has no name`},
+		{Builder{}, Number(20), `can't unparse "-20": number options need NumberOptions`},
+		{Builder{NumberOptions: true}, Short('2'), `can't unparse "-2": it would read back as a number option`},
+		{Builder{}, Plus('p'), `can't unparse "+p": plus options need PlusOptions`},
+		{Builder{}, PlusLong("x"), `can't unparse "++x": plus options need PlusOptions`},
+	}
+
+	for _, test := range tests {
+		if err := test.b.Option(test.opt); err == nil || err.Error() != test.expect {
+			t.Errorf("%#v: want %s, got %v", test.opt, test.expect, err)
+		}
+
+		if got := test.b.Argv(); len(got) != 0 {
+			t.Errorf("%#v: want nothing added, got %q", test.opt, got)
+		}
+	}
+
+	// A long option with no name can have a value, as --=value.
+	var b Builder
+	if err := b.OptionValue(Long(""), "x"); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Argv(); !slices.Equal(got, []string{"--=x"}) {
+		t.Errorf("want [--=x], got %q", got)
+	}
+
+	// Digits are short options without NumberOptions.
+	if err := b.Option(Short('2')); err != nil {
+		t.Errorf("want no error for -2 without NumberOptions, got %v", err)
+	}
+}
+
+// TestBuilderRoundTrip parses command lines, builds them again from the
+// options and values that were found, and checks that a parser finds the
+// same options and values in the result.
+func TestBuilderRoundTrip(t *testing.T) {
+	takes := []Arg{Short('o'), Long("out"), Plus('p'), PlusLong("set"), Number(5), Long("")}
+
+	argvs := [][]string{
+		{"-o", "-x", "--out", "--", "pos"},
+		{"-vo-", "--out=--", "-o=", "--out", "="},
+		{"-o", "--", "--", "-v", "--out"},
+		{"a", "-", "b", "--", "+p"},
+		{"--", "--", "-"},
+		{"+p", "-v", "++set=+x", "+vp", "-5", "-9", "-5", "+", "++"},
+		{"-o", "a b", "--out", "'\"\\"},
+		{"x", "—verbose", "–o"},
+		{"--=x", "-20", "-3", "-5", "-1"},
+		{},
+	}
+
+	// parse returns what a program driving p would see.
+	parse := func(p *Parser) (opts []string, pos []string) {
+		for p.Next() {
+			if !p.Current.IsOption() {
+				pos = append(pos, p.Current.s)
+				continue
+			}
+
+			opt := p.Current.DashedString()
+			if slices.Contains(takes, p.Current) {
+				val, err := p.Value()
+				if err != nil {
+					t.Fatal(err)
+				}
+				opt += " " + fmt.Sprintf("%q", val.s)
+			}
+			opts = append(opts, opt)
+		}
+
+		if err := p.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return opts, pos
+	}
+
+	for _, mode := range []Builder{{}, {PlusOptions: true}, {NumberOptions: true}} {
+		plus, number := mode.PlusOptions, mode.NumberOptions
+		newParser := func(argv []string) *Parser {
+			p := NewFromArgs(argv)
+			p.PlusOptions = plus
+			p.NumberOptions = number
+			return p
+		}
+
+		for _, argv := range argvs {
+			t.Run(fmt.Sprint(plus, number, argv), func(t *testing.T) {
+				p := newParser(argv)
+
+				b := Builder{PlusOptions: plus, NumberOptions: number}
+				for p.Next() {
+					var err error
+					switch {
+					case !p.Current.IsOption():
+						b.Positional(p.Current.s)
+					case slices.Contains(takes, p.Current):
+						opt := p.Current
+						var val Arg
+						if val, err = p.Value(); err == nil {
+							err = b.OptionValue(opt, val.s)
+						}
+					default:
+						err = b.Option(p.Current)
+					}
+
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				if err := p.Err(); err != nil {
+					t.Fatal(err)
+				}
+
+				p = newParser(argv)
+				wantOpts, wantPos := parse(p)
+
+				rebuilt := b.Argv()
+				p = newParser(rebuilt)
+				gotOpts, gotPos := parse(p)
+
+				if !slices.Equal(gotOpts, wantOpts) || !slices.Equal(gotPos, wantPos) {
+					t.Errorf("rebuilt as %q\nwant %q %q\ngot  %q %q", rebuilt, wantOpts, wantPos, gotOpts, gotPos)
+				}
+
+				// Building again from the canonical form changes nothing.
+				p = newParser(rebuilt)
+				again := Builder{PlusOptions: plus, NumberOptions: number}
+				var opt Arg
+				for _, tok := range p.Tokenize(takes...) {
+					var err error
+					switch tok.Kind {
+					case TokenOption:
+						opt = tok.Arg
+						if !slices.Contains(takes, opt) {
+							err = again.Option(opt)
+						}
+					case TokenValue:
+						err = again.OptionValue(opt, tok.Arg.s)
+					case TokenPositional:
+						again.Positional(tok.Arg.s)
+					}
+
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				if got := again.Argv(); !slices.Equal(got, rebuilt) {
+					t.Errorf("not canonical:\nwant %q\ngot  %q", rebuilt, got)
+				}
+			})
+		}
+	}
+}